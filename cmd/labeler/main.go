@@ -0,0 +1,248 @@
+// Command labeler labels the texts the bot queued for active learning
+// (the labeling section of configs/model.yaml) and exports them for
+// retraining:
+//
+//	labeler label  [-user name]
+//	labeler stats
+//	labeler export [-out data/sentiment] [-validation 0.2]
+//	labeler compact
+//
+// label offers the most useful pending text first: one the models disagree
+// on, else the most uncertain. Answer with a label's number or first letter,
+// s to skip a text that can't be labelled, or q to quit; every answer is
+// saved immediately. export writes train.csv and validation.csv with text
+// and label columns for scripts/retrain_sentiment.py. The bot may keep
+// queueing texts while labeler runs, but not during compact.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Bhavik2205/ML-Bot/internal/model"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	configPath := fs.String("config", model.DefaultModelConfigPath, "model definitions file")
+	queuePath := fs.String("queue", "", "label queue file (default from the config)")
+	labeler := fs.String("user", defaultUser(), "name recorded with each label")
+	out := fs.String("out", "data/sentiment", "directory for train.csv and validation.csv (export)")
+	validation := fs.Float64("validation", 0.2, "fraction of examples for validation (export)")
+	asJSON := fs.Bool("json", false, "print stats or the export summary as JSON")
+	fs.Parse(args)
+
+	cfg, err := model.LoadModelConfig(*configPath)
+	if err != nil {
+		fmt.Println("Error loading model config:", err)
+		os.Exit(1)
+	}
+	if *queuePath == "" {
+		*queuePath = cfg.Labeling.Queue
+	}
+	if *queuePath == "" {
+		fmt.Println("No label queue: set labeling.queue in", *configPath, "or pass -queue")
+		os.Exit(2)
+	}
+	def, err := cfg.Model("")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	queue, err := model.OpenLabelQueue(*queuePath)
+	if err != nil {
+		fmt.Println("Error opening label queue:", err)
+		os.Exit(1)
+	}
+	defer queue.Close()
+
+	switch cmd {
+	case "label":
+		err = label(queue, def.Labels, *labeler)
+	case "stats":
+		err = printStats(queue.Stats(), *asJSON)
+	case "export":
+		var exp model.DatasetExport
+		exp, err = queue.Export(*out, *validation, def.Labels)
+		if err == nil {
+			err = printExport(exp, *asJSON)
+		}
+	case "compact":
+		err = queue.Compact()
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Println("usage: labeler label|stats|export|compact [flags]")
+	os.Exit(2)
+}
+
+func defaultUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// label asks for labels until the queue is empty or the user quits. The
+// queue is reloaded before each text so that items the bot added meanwhile
+// are offered too.
+func label(queue *model.LabelQueue, labels []string, labeler string) error {
+	keys := labelKeys(labels)
+	var prompt []string
+	for i, l := range labels {
+		if keys[l] == l {
+			prompt = append(prompt, fmt.Sprintf("%d=%s", i+1, l))
+		} else {
+			prompt = append(prompt, fmt.Sprintf("%d/%s=%s", i+1, keys[l], l))
+		}
+	}
+	promptLine := strings.Join(prompt, "  ") + "  s=skip  q=quit > "
+
+	in := bufio.NewScanner(os.Stdin)
+	done := 0
+	for {
+		if err := queue.Reload(); err != nil {
+			return err
+		}
+		item, found := queue.Next()
+		if !found {
+			fmt.Printf("Queue is empty. Labelled %d text(s) this session.\n", done)
+			return nil
+		}
+
+		stats := queue.Stats()
+		fmt.Printf("\n[%d pending] %s\n", stats.Pending, strings.Join(item.Reasons, ", "))
+		fmt.Printf("%s\n", item.Text)
+		fmt.Printf("Model %s: %s (%.2f)", item.Model, item.Predicted, item.Confidence)
+		for _, m := range sortedKeys(item.Candidates) {
+			fmt.Printf(" | %s: %s", m, item.Candidates[m])
+		}
+		fmt.Println()
+
+		for {
+			fmt.Print(promptLine)
+			if !in.Scan() {
+				fmt.Printf("\nLabelled %d text(s) this session.\n", done)
+				return in.Err()
+			}
+			answer := strings.ToLower(strings.TrimSpace(in.Text()))
+			switch answer {
+			case "q", "quit":
+				fmt.Printf("Labelled %d text(s) this session.\n", done)
+				return nil
+			case "s", "skip":
+				if err := queue.Skip(item.ID, labeler); err != nil {
+					return err
+				}
+			default:
+				l := parseLabel(answer, labels, keys)
+				if l == "" {
+					fmt.Println("Unknown answer:", answer)
+					continue
+				}
+				if err := queue.SetLabel(item.ID, l, labeler); err != nil {
+					return err
+				}
+				done++
+			}
+			break
+		}
+	}
+}
+
+// labelKeys gives each label its first letter as a shortcut, unless two
+// labels share it; those are answered by number or in full.
+func labelKeys(labels []string) map[string]string {
+	count := make(map[string]int)
+	for _, l := range labels {
+		count[l[:1]]++
+	}
+	keys := make(map[string]string, len(labels))
+	for _, l := range labels {
+		if count[l[:1]] == 1 && l[:1] != "s" && l[:1] != "q" {
+			keys[l] = l[:1]
+		} else {
+			keys[l] = l
+		}
+	}
+	return keys
+}
+
+func parseLabel(answer string, labels []string, keys map[string]string) string {
+	for i, l := range labels {
+		if answer == l || answer == keys[l] || answer == fmt.Sprint(i+1) {
+			return l
+		}
+	}
+	return ""
+}
+
+func printStats(s model.LabelQueueStats, asJSON bool) error {
+	if asJSON {
+		return printJSON(s)
+	}
+	fmt.Printf("Total %d | pending %d | labelled %d | skipped %d\n", s.Total, s.Pending, s.Labeled, s.Skipped)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tCOUNT")
+	for _, l := range sortedKeys(s.ByLabel) {
+		fmt.Fprintf(tw, "%s\t%d\n", l, s.ByLabel[l])
+	}
+	fmt.Fprintln(tw, "\nREASON\tCOUNT")
+	for _, r := range sortedKeys(s.ByReason) {
+		fmt.Fprintf(tw, "%s\t%d\n", r, s.ByReason[r])
+	}
+	return tw.Flush()
+}
+
+func printExport(exp model.DatasetExport, asJSON bool) error {
+	if asJSON {
+		return printJSON(exp)
+	}
+	fmt.Printf("Wrote %d training examples to %s and %d validation examples to %s\n",
+		exp.TrainCount, exp.Train, exp.ValidationCount, exp.Validation)
+	for _, l := range sortedKeys(exp.ByLabel) {
+		fmt.Printf("  %s: %d\n", l, exp.ByLabel[l])
+	}
+	if exp.Ignored > 0 {
+		fmt.Printf("Ignored %d example(s) with labels the model doesn't have\n", exp.Ignored)
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
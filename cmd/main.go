@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -11,13 +19,81 @@ import (
 	"github.com/Bhavik2205/ML-Bot/internal/model"
 )
 
+// explainMode prints word attributions after each article's sentiment.
+var explainMode bool
+
+// entities are the companies that get their own sentiment when an article
+// mentions them.
+var entities []model.Entity
+
+// stories groups articles covering the same event so that the sentiment
+// index counts a story repeated by many outlets with less weight.
+var stories = data.NewStoryClusterer(0, 0)
+
+// archive, when set with -archive, records every article with the company
+// it is about, for building training sets with cmd/weaklabel.
+var archive *json.Encoder
+
 func main() {
+	stream := flag.String("stream", "", "comma-separated symbols to follow on the Finnhub news websocket")
+	company := flag.String("company", "", "run the multi-source news pipeline for this company or ticker")
+	jsonReport := flag.Bool("json", false, "print the news pipeline report (or -text explanation) as JSON")
+	explain := flag.Bool("explain", false, "show the words behind each article's sentiment")
+	text := flag.String("text", "", "score and explain a single text")
+	archivePath := flag.String("archive", "", "append articles with their company to this JSON lines file (-company and -stream)")
+	flag.Parse()
+
 	err := godotenv.Load()
 	if err != nil {
 		fmt.Println("❌ Error loading .env file")
 		return
 	}
 
+	// Load the sentiment model up front so that a missing or corrupt model
+	// file, such as an unfetched Git LFS pointer, is reported before any
+	// news is fetched.
+	def, err := model.LoadDefaultAnalyzer()
+	if err != nil {
+		fmt.Println("❌ Error loading sentiment model:", err)
+		return
+	}
+	fmt.Printf("Sentiment model: %s %s\n", def.Name, def.Version)
+
+	entities, err = model.LoadEntities(model.DefaultEntitiesPath)
+	if err != nil {
+		fmt.Println("Company aliases unavailable, skipping per-company sentiment:", err)
+	}
+
+	if *archivePath != "" {
+		if err := os.MkdirAll(filepath.Dir(*archivePath), 0o755); err != nil {
+			fmt.Println("Error creating archive directory:", err)
+			return
+		}
+		f, err := os.OpenFile(*archivePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Println("Error opening archive:", err)
+			return
+		}
+		defer f.Close()
+		archive = json.NewEncoder(f)
+	}
+
+	explainMode = *explain
+	if *text != "" {
+		explainText(*text, *jsonReport)
+		return
+	}
+
+	if *stream != "" {
+		runStream(strings.Split(*stream, ","))
+		return
+	}
+
+	if *company != "" {
+		runPipeline(*company, *jsonReport)
+		return
+	}
+
 	apiKey := os.Getenv("NEWS_API_KEY")
 	if apiKey == "" {
 		fmt.Println("Please set the NEWS_API_KEY environment variable.")
@@ -33,19 +109,277 @@ func main() {
 	fmt.Printf("Fetched %d news articles.\n", len(newsArticles))
 
 	for i, article := range newsArticles {
-		// Combine title and description
-		text := article.Title + " " + article.Description
-		cleanText := data.CleanText(text)
-		fmt.Print("Clean: ", cleanText)
-		sentiment, confidence, err := model.AnalyzeSentiment(cleanText)
+		analyzeArticle(i+1, article.Title, article.Description, article.Source.Name, article.PublishedAt)
+	}
+}
+
+// runStream follows live Finnhub news for the given symbols until interrupted.
+// SIGHUP hot-swaps the sentiment model to its latest registered version.
+func runStream(symbols []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			if err := model.SwapDefaultModel(""); err != nil {
+				fmt.Println("Error swapping sentiment model:", err)
+			}
+		}
+	}()
+
+	go pruneStories(ctx, time.Hour)
+
+	n := 0
+	s := data.NewFinnhubStream(data.FinnhubStreamConfig{Symbols: symbols})
+	err := s.Run(ctx, func(article data.NewsArticle) {
+		n++
+		for _, r := range analyzeArticle(n, article.Title, article.Description, article.Source, article.PublishedAt) {
+			archiveArticle(r.Entity, article)
+			indexArticle(r.Entity, article, r.Sentiment)
+			printIndex(r.Entity, time.Now())
+		}
+	})
+	if err != nil && err != context.Canceled {
+		fmt.Println("Error streaming news:", err)
+	}
+}
+
+// pruneStories periodically forgets the stories no new article can join,
+// until ctx is done, so that a long-running stream doesn't keep every story
+// it has seen. The sentiment index keeps the story IDs of its own articles.
+func pruneStories(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Articles can arrive late with an earlier publish time, so keep
+			// a window more than the clusterer needs.
+			stories.Prune(now.Add(-2 * stories.Window))
+		}
+	}
+}
+
+// runPipeline fetches company news from all configured sources, prints the
+// run report and scores the unique articles.
+func runPipeline(company string, jsonReport bool) {
+	target := model.FindEntity(entities, company)
+	if !containsEntity(entities, target.Name) {
+		entities = append(entities, target)
+	}
+
+	// Only articles whose high-impact claims trusted outlets confirm are
+	// indexed at full trust.
+	cfg := data.DefaultNewsPipelineConfig()
+	cfg.Stories = stories
+	cfg.Corroborator = data.NewCorroborator()
+	report, err := data.RunNewsPipelineReport(context.Background(), company, &cfg)
+	if err != nil {
+		fmt.Println("Error fetching news:", err)
+	}
+
+	if jsonReport {
+		out, err := report.JSON()
 		if err != nil {
-			fmt.Printf("Error analyzing article %d: %v\n", i+1, err)
+			fmt.Println("Error encoding report:", err)
+			return
+		}
+		fmt.Println(string(out))
+		return
+	}
+	report.WriteText(os.Stdout)
+
+	// Score all articles in batched model runs.
+	texts := make([]string, len(report.Articles))
+	for i, article := range report.Articles {
+		texts[i] = data.CleanSentimentText(article.Title + " " + article.Description)
+	}
+	results, err := model.AnalyzeBatch(texts)
+	if err != nil {
+		fmt.Println("Error analyzing articles:", err)
+		return
+	}
+	for i, article := range report.Articles {
+		if results[i].Err != nil {
+			fmt.Printf("Error analyzing article %d: %v\n", i+1, results[i].Err)
 			continue
 		}
+		printSentiment(i+1, article.Title, article.Source, article.PublishedAt, results[i].SentimentResult)
+		printExplanation(texts[i])
 
-		fmt.Printf("\nArticle #%d:\n", i+1)
-		fmt.Printf("Title: %s\n", article.Title)
-		fmt.Printf("Source: %s | Published: %s\n", article.Source.Name, article.PublishedAt.Format("2006-01-02"))
-		fmt.Printf("Sentiment: %s (%.2f confidence)\n", sentiment, confidence)
+		// The company's index takes the sentiment of the parts of the
+		// article about it when it is named, the whole article otherwise.
+		sentiment := results[i].SentimentResult
+		for _, r := range printEntitySentiment(article.Title, article.Description) {
+			if r.Entity == target.Name {
+				sentiment = r.Sentiment
+			}
+		}
+		archiveArticle(target.Name, article)
+		indexArticle(target.Name, article, sentiment)
+	}
+	printIndex(target.Name, time.Now())
+}
+
+// analyzeArticle scores and prints one article and returns the sentiment of
+// each known company it mentions.
+func analyzeArticle(n int, title, description, source string, publishedAt time.Time) []model.EntitySentiment {
+	// Combine title and description
+	text := title + " " + description
+	cleanText := data.CleanSentimentText(text)
+	fmt.Print("Clean: ", cleanText)
+	result, err := model.AnalyzeSentiment(cleanText)
+	if err != nil {
+		fmt.Printf("Error analyzing article %d: %v\n", n, err)
+		return nil
+	}
+
+	printSentiment(n, title, source, publishedAt, result)
+	printExplanation(cleanText)
+	return printEntitySentiment(title, description)
+}
+
+func printSentiment(n int, title, source string, publishedAt time.Time, result model.SentimentResult) {
+	fmt.Printf("\nArticle #%d:\n", n)
+	fmt.Printf("Title: %s\n", title)
+	fmt.Printf("Source: %s | Published: %s\n", source, publishedAt.Format("2006-01-02"))
+	fmt.Printf("Sentiment: %s (%.2f confidence) | score %+.2f | uncertainty %.2f\n",
+		result.Label, result.Confidence, result.Score, result.Uncertainty)
+	fmt.Printf("Probabilities: negative %.2f | neutral %.2f | positive %.2f\n",
+		result.Probability("negative"), result.Probability("neutral"), result.Probability("positive"))
+	if result.Truncated {
+		fmt.Println("Note: text was truncated to the model's max length")
+	}
+}
+
+// explainText prints the sentiment of one text with the words behind it.
+func explainText(text string, asJSON bool) {
+	exp, err := model.ExplainSentiment(text)
+	if err != nil {
+		fmt.Println("Error explaining sentiment:", err)
+		return
+	}
+	if asJSON {
+		out, err := exp.JSON()
+		if err != nil {
+			fmt.Println("Error encoding explanation:", err)
+			return
+		}
+		fmt.Println(string(out))
+		return
+	}
+	exp.WriteHighlighted(os.Stdout)
+}
+
+// printExplanation highlights the words behind the sentiment of text when
+// -explain is set. This re-scores the text once per word.
+func printExplanation(text string) {
+	if !explainMode {
+		return
+	}
+	exp, err := model.ExplainSentiment(text)
+	if err != nil {
+		fmt.Println("Error explaining sentiment:", err)
+		return
+	}
+	exp.WriteHighlighted(os.Stdout)
+}
+
+// printEntitySentiment prints the sentiment of each known company the
+// article mentions, from only the sentences and clauses about it. The
+// uncleaned text is used because cleaning strips sentence punctuation.
+func printEntitySentiment(title, description string) []model.EntitySentiment {
+	if len(entities) == 0 {
+		return nil
+	}
+	results, err := model.AnalyzeTargeted(title+". "+description, entities)
+	if err != nil {
+		fmt.Println("Error analyzing company sentiment:", err)
+	}
+	for _, r := range results {
+		note := ""
+		if r.Shared {
+			note = " (shared with other companies)"
+		}
+		fmt.Printf("Company %s: %s | score %+.2f | %d mention(s)%s\n",
+			r.Entity, r.Sentiment.Label, r.Sentiment.Score, r.Mentions, note)
+	}
+	return results
+}
+
+// archiveArticle records the article as being about company when -archive is set.
+func archiveArticle(company string, article data.NewsArticle) {
+	if archive == nil {
+		return
+	}
+	err := archive.Encode(model.NewsRecord{
+		Ticker:      company,
+		Source:      article.Source,
+		Title:       article.Title,
+		Description: article.Description,
+		URL:         article.URL,
+		PublishedAt: article.PublishedAt,
+	})
+	if err != nil {
+		fmt.Println("Error archiving article:", err)
+	}
+}
+
+// indexArticle adds a scored article to the company's sentiment index,
+// trusting the source as much as the corroboration check did. High-impact
+// claims no trusted outlet carried are left out.
+func indexArticle(company string, article data.NewsArticle, sentiment model.SentimentResult) {
+	index, err := model.DefaultSentimentIndex()
+	if err != nil {
+		return
+	}
+	a := model.IndexedArticle{
+		Ticker:      company,
+		Source:      article.Source,
+		StoryID:     stories.Add(article),
+		PublishedAt: article.PublishedAt,
+		AvailableAt: time.Now(),
+		Sentiment:   sentiment,
+	}
+	if c := article.Corroboration; c != nil {
+		switch {
+		case c.Uncorroborated():
+			fmt.Println("Not indexing uncorroborated high-impact article:", article.Title)
+			return
+		case c.Checked:
+			a.Trust = c.Score
+		default:
+			a.Trust = c.SourceTrust
+		}
+	}
+	if err := index.Add(a); err != nil {
+		fmt.Println("Error indexing article:", err)
+	}
+}
+
+func printIndex(company string, at time.Time) {
+	index, err := model.DefaultSentimentIndex()
+	if err != nil {
+		return
+	}
+	v := index.At(company, at)
+	if v.Articles == 0 {
+		return
+	}
+	fmt.Printf("Sentiment index %s: %+.2f | %d article(s) in %d story(ies) | effective %.1f | dispersion %.2f\n",
+		v.Ticker, v.Value, v.Articles, v.Stories, v.Effective, v.Dispersion)
+}
+
+func containsEntity(entities []model.Entity, name string) bool {
+	for _, e := range entities {
+		if e.Name == name {
+			return true
+		}
 	}
+	return false
 }
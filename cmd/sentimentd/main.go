@@ -0,0 +1,490 @@
+// Command sentimentd serves sentiment scores over HTTP so that services other
+// than the trading bot can use the FinBERT model. Concurrent requests are
+// micro-batched into shared model runs. The classifiers of the model config
+// are served at /v1/classify.
+//
+// The server listens on localhost by default. The /v1/admin endpoints, which
+// swap the model and reset drift detection, accept only local clients unless
+// SENTIMENTD_ADMIN_TOKEN is set, in which case they require it as a bearer
+// token from every client.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Bhavik2205/ML-Bot/internal/model"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sentimentd_request_duration_seconds",
+			Help:    "Duration of sentimentd HTTP requests per endpoint",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 10), // 5ms to ~2.5s
+		},
+		[]string{"endpoint", "code"},
+	)
+	textsScored = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentimentd_texts_total",
+			Help: "Total number of texts scored per endpoint and outcome",
+		},
+		[]string{"endpoint", "outcome"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, textsScored)
+}
+
+type server struct {
+	ready    atomic.Bool
+	batcher  atomic.Pointer[model.MicroBatcher]
+	analyzer atomic.Pointer[model.SwappableAnalyzer]
+	maxTexts int
+	entities []model.Entity
+
+	adminToken string // required by /v1/admin/*; local clients only if empty
+
+	// Set before ready; read only once ready.
+	classifiers map[string]*model.TextClassifier
+}
+
+type scoreRequest struct {
+	Text string `json:"text"`
+}
+
+type batchRequest struct {
+	Texts []string `json:"texts"`
+}
+
+// entitiesRequest names the companies to score by name or alias from the
+// entities file; all known companies if empty. Entities not in the file can
+// be sent with their aliases.
+type entitiesRequest struct {
+	Text      string         `json:"text"`
+	Companies []string       `json:"companies"`
+	Entities  []model.Entity `json:"entities"`
+}
+
+// classifyRequest names the classifier from the classifiers section of the
+// model config.
+type classifyRequest struct {
+	Model string   `json:"model"`
+	Texts []string `json:"texts"`
+}
+
+type classifyItem struct {
+	*model.ClassifierResult
+	Error string `json:"error,omitempty"`
+}
+
+type swapRequest struct {
+	Version string `json:"version"` // latest registered version if empty
+}
+
+type batchItem struct {
+	*model.SentimentResult
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8090", "HTTP listen address")
+	configPath := flag.String("config", model.DefaultModelConfigPath, "model definitions file")
+	modelName := flag.String("model", "", "model to serve (default model from the config if empty)")
+	maxBatch := flag.Int("max-batch", 16, "maximum texts per micro-batched model run")
+	maxDelay := flag.Duration("max-delay", 10*time.Millisecond, "maximum time a text waits for its batch to fill")
+	workers := flag.Int("workers", 2, "concurrent micro-batch workers")
+	maxTexts := flag.Int("max-texts", 256, "maximum texts per batch request")
+	entitiesPath := flag.String("entities", model.DefaultEntitiesPath, "company aliases for /v1/sentiment/entities")
+	flag.Parse()
+
+	// .env is optional for the server; settings may come from the environment.
+	_ = godotenv.Load()
+
+	s := &server{maxTexts: *maxTexts, adminToken: os.Getenv("SENTIMENTD_ADMIN_TOKEN")}
+	entities, err := model.LoadEntities(*entitiesPath)
+	if err != nil {
+		fmt.Println("Company aliases unavailable, entities must be sent with each request:", err)
+	}
+	s.entities = entities
+
+	// Load the model in the background so that liveness probes pass while
+	// the (slow) session creation runs; readiness flips once it is done.
+	var analyzer *model.SwappableAnalyzer
+	loaded := make(chan error, 1)
+	go func() {
+		cfg, err := model.LoadModelConfig(*configPath)
+		if err != nil {
+			loaded <- err
+			return
+		}
+		analyzer, err = model.NewSwappableAnalyzer(cfg, *modelName)
+		if err != nil {
+			loaded <- err
+			return
+		}
+		s.classifiers = make(map[string]*model.TextClassifier, len(cfg.Classifiers))
+		for name, def := range cfg.Classifiers {
+			c, err := model.NewTextClassifier(def)
+			if err != nil {
+				loaded <- fmt.Errorf("classifier %q: %w", name, err)
+				return
+			}
+			s.classifiers[name] = c
+		}
+		s.analyzer.Store(analyzer)
+		s.batcher.Store(model.NewMicroBatcher(analyzer, *maxBatch, *maxDelay, *workers))
+		s.ready.Store(true)
+		loaded <- nil
+	}()
+
+	srv := &http.Server{Addr: *addr, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		fmt.Println("sentimentd listening on", *addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println("HTTP server failed:", err)
+			os.Exit(1)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-loaded:
+		if err != nil {
+			fmt.Println("Error loading sentiment model:", err)
+			os.Exit(1)
+		}
+		def := analyzer.Current()
+		fmt.Printf("Sentiment model %s %s loaded\n", def.Name, def.Version)
+		for name, c := range s.classifiers {
+			fmt.Printf("Classifier %s %s loaded\n", name, c.Definition().Version)
+		}
+		<-ctx.Done()
+	case <-ctx.Done():
+	}
+
+	// Stop taking traffic, drain in-flight requests, then free the model.
+	s.ready.Store(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("Error shutting down HTTP server:", err)
+	}
+	// The batcher is stored after analyzer is set, so a non-nil batcher
+	// means the analyzer is ready to be closed too.
+	if b := s.batcher.Load(); b != nil {
+		b.Close()
+		analyzer.Close()
+		for _, c := range s.classifiers {
+			c.Close()
+		}
+	}
+}
+
+// routes returns the server's endpoints.
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/sentiment", s.instrument("sentiment", s.handleScore))
+	mux.Handle("/v1/sentiment/batch", s.instrument("batch", s.handleBatch))
+	mux.Handle("/v1/sentiment/entities", s.instrument("entities", s.handleEntities))
+	mux.Handle("/v1/sentiment/explain", s.instrument("explain", s.handleExplain))
+	mux.Handle("/v1/classify", s.instrument("classify", s.handleClassify))
+	mux.Handle("/v1/admin/swap", s.instrument("swap", s.admin(s.handleSwap)))
+	mux.Handle("/v1/drift", s.instrument("drift", s.handleDrift))
+	mux.Handle("/v1/admin/drift/reset", s.instrument("drift_reset", s.admin(s.handleDriftReset)))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready.Load() {
+			http.Error(w, "model loading", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+func (s *server) handleScore(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	b := s.batcher.Load()
+	if b == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req scoreRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+
+	res, err := b.Analyze(r.Context(), req.Text)
+	if err != nil {
+		textsScored.WithLabelValues("sentiment", "error").Inc()
+		return writeError(w, errorStatus(err), err.Error())
+	}
+	textsScored.WithLabelValues("sentiment", "ok").Inc()
+	return writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) handleBatch(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	b := s.batcher.Load()
+	if b == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<20)).Decode(&req); err != nil {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+	if len(req.Texts) == 0 {
+		return writeError(w, http.StatusBadRequest, "texts is required")
+	}
+	if len(req.Texts) > s.maxTexts {
+		return writeError(w, http.StatusRequestEntityTooLarge, "too many texts, max "+strconv.Itoa(s.maxTexts))
+	}
+
+	results := b.AnalyzeBatch(r.Context(), req.Texts)
+	items := make([]batchItem, len(results))
+	for i := range results {
+		if results[i].Err != nil {
+			textsScored.WithLabelValues("batch", "error").Inc()
+			items[i].Error = results[i].Err.Error()
+			continue
+		}
+		textsScored.WithLabelValues("batch", "ok").Inc()
+		items[i].SentimentResult = &results[i].SentimentResult
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"results": items})
+}
+
+func (s *server) handleEntities(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	a := s.analyzer.Load()
+	if a == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req entitiesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+	entities := req.Entities
+	for _, name := range req.Companies {
+		entities = append(entities, model.FindEntity(s.entities, name))
+	}
+	if len(entities) == 0 {
+		entities = s.entities
+	}
+
+	results, err := model.AnalyzeEntities(a, req.Text, entities)
+	if err != nil {
+		textsScored.WithLabelValues("entities", "error").Inc()
+		return writeError(w, errorStatus(err), err.Error())
+	}
+	textsScored.WithLabelValues("entities", "ok").Inc()
+	if results == nil {
+		results = []model.EntitySentiment{}
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"entities": results})
+}
+
+// handleExplain returns the prediction with word attributions. It runs one
+// model row per word, so it bypasses the micro-batcher.
+func (s *server) handleExplain(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	a := s.analyzer.Load()
+	if a == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req scoreRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+	exp, err := a.Explain(req.Text)
+	if err != nil {
+		textsScored.WithLabelValues("explain", "error").Inc()
+		return writeError(w, errorStatus(err), err.Error())
+	}
+	textsScored.WithLabelValues("explain", "ok").Inc()
+	return writeJSON(w, http.StatusOK, exp)
+}
+
+// handleClassify runs texts through one of the configured classifiers.
+func (s *server) handleClassify(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	if !s.ready.Load() {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req classifyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<20)).Decode(&req); err != nil {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+	c, found := s.classifiers[req.Model]
+	if !found {
+		return writeError(w, http.StatusNotFound, fmt.Sprintf("classifier %q is not configured", req.Model))
+	}
+	if len(req.Texts) == 0 {
+		return writeError(w, http.StatusBadRequest, "texts is required")
+	}
+	if len(req.Texts) > s.maxTexts {
+		return writeError(w, http.StatusRequestEntityTooLarge, "too many texts, max "+strconv.Itoa(s.maxTexts))
+	}
+
+	results := c.ClassifyBatch(req.Texts)
+	items := make([]classifyItem, len(results))
+	for i := range results {
+		if results[i].Err != nil {
+			textsScored.WithLabelValues("classify", "error").Inc()
+			items[i].Error = results[i].Err.Error()
+			continue
+		}
+		textsScored.WithLabelValues("classify", "ok").Inc()
+		items[i].ClassifierResult = &results[i].ClassifierResult
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"results": items})
+}
+
+// handleSwap hot-swaps the served model to a registered version. Requests in
+// flight finish on the old model. Like every /v1/admin endpoint it is
+// guarded by admin: it takes the SENTIMENTD_ADMIN_TOKEN bearer token when
+// one is set, and only loopback clients otherwise.
+func (s *server) handleSwap(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	a := s.analyzer.Load()
+	if a == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+
+	var req swapRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+	}
+	if err := a.SwapVersion(req.Version); err != nil {
+		return writeError(w, http.StatusUnprocessableEntity, err.Error())
+	}
+	def := a.Current()
+	return writeJSON(w, http.StatusOK, map[string]string{"model": def.Name, "version": def.Version})
+}
+
+// handleDrift returns the latest drift comparison of the served model.
+func (s *server) handleDrift(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodGet {
+		return writeError(w, http.StatusMethodNotAllowed, "use GET")
+	}
+	a := s.analyzer.Load()
+	if a == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+	drift := a.Drift()
+	if drift == nil {
+		return writeError(w, http.StatusNotFound, "drift detection is not configured")
+	}
+	return writeJSON(w, http.StatusOK, drift.Status())
+}
+
+// handleDriftReset starts a new drift reference from the next predictions.
+func (s *server) handleDriftReset(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodPost {
+		return writeError(w, http.StatusMethodNotAllowed, "use POST")
+	}
+	a := s.analyzer.Load()
+	if a == nil {
+		return writeError(w, http.StatusServiceUnavailable, "model loading")
+	}
+	drift := a.Drift()
+	if drift == nil {
+		return writeError(w, http.StatusNotFound, "drift detection is not configured")
+	}
+	if err := drift.ResetReference(); err != nil {
+		return writeError(w, http.StatusInternalServerError, err.Error())
+	}
+	return writeJSON(w, http.StatusOK, drift.Status())
+}
+
+// instrument records request latency by endpoint and status code.
+func (s *server) instrument(endpoint string, h func(http.ResponseWriter, *http.Request) int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		code := h(w, r)
+		requestDuration.WithLabelValues(endpoint, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// admin guards an admin endpoint: with a token configured the request must
+// carry it as "Authorization: Bearer <token>", otherwise it must come from a
+// loopback address.
+func (s *server) admin(h func(http.ResponseWriter, *http.Request) int) func(http.ResponseWriter, *http.Request) int {
+	return func(w http.ResponseWriter, r *http.Request) int {
+		if s.adminToken != "" {
+			token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				return writeError(w, http.StatusUnauthorized, "missing or invalid admin token")
+			}
+			return h(w, r)
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			return writeError(w, http.StatusForbidden, "admin endpoints are local only; set SENTIMENTD_ADMIN_TOKEN to allow remote clients")
+		}
+		return h(w, r)
+	}
+}
+
+// errorStatus is the status for a text that could not be scored: 400 for
+// input no model can score, 422 otherwise.
+func errorStatus(err error) int {
+	if errors.Is(err, model.ErrEmptyText) {
+		return http.StatusBadRequest
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+	return code
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) int {
+	return writeJSON(w, code, map[string]string{"error": msg})
+}
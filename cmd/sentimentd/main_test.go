@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bhavik2205/ML-Bot/internal/model"
+)
+
+// newTestServer serves the lexicon model, which needs no model files.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.yaml")
+	cfg := "default: lexicon\nregistry: " + filepath.Join(dir, "registry.yaml") + "\nmodels:\n  lexicon:\n    type: lexicon\n"
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modelCfg, err := model.LoadModelConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer, err := model.NewSwappableAnalyzer(modelCfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	batcher := model.NewMicroBatcher(analyzer, 4, time.Millisecond, 1)
+	t.Cleanup(func() {
+		batcher.Close()
+		analyzer.Close()
+	})
+
+	s := &server{maxTexts: 2, adminToken: "secret", classifiers: map[string]*model.TextClassifier{}}
+	s.analyzer.Store(analyzer)
+	s.batcher.Store(batcher)
+	s.ready.Store(true)
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestHandlersRejectBadInput(t *testing.T) {
+	ts := newTestServer(t)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"score", http.MethodPost, "/v1/sentiment", `{"text": "Infosys profit surged"}`, http.StatusOK},
+		{"score GET", http.MethodGet, "/v1/sentiment", "", http.StatusMethodNotAllowed},
+		{"score malformed", http.MethodPost, "/v1/sentiment", `{"text": `, http.StatusBadRequest},
+		{"score wrong type", http.MethodPost, "/v1/sentiment", `{"text": 5}`, http.StatusBadRequest},
+		{"score empty text", http.MethodPost, "/v1/sentiment", `{"text": "  "}`, http.StatusBadRequest},
+		{"score no body", http.MethodPost, "/v1/sentiment", "", http.StatusBadRequest},
+		{"batch", http.MethodPost, "/v1/sentiment/batch", `{"texts": ["profit surged", ""]}`, http.StatusOK},
+		{"batch malformed", http.MethodPost, "/v1/sentiment/batch", `{"texts": "one"}`, http.StatusBadRequest},
+		{"batch no texts", http.MethodPost, "/v1/sentiment/batch", `{"texts": []}`, http.StatusBadRequest},
+		{"batch too many", http.MethodPost, "/v1/sentiment/batch", `{"texts": ["a", "b", "c"]}`, http.StatusRequestEntityTooLarge},
+		{"entities malformed", http.MethodPost, "/v1/sentiment/entities", `[]`, http.StatusBadRequest},
+		{"entities empty text", http.MethodPost, "/v1/sentiment/entities", `{"text": ""}`, http.StatusBadRequest},
+		{"explain malformed", http.MethodPost, "/v1/sentiment/explain", `{`, http.StatusBadRequest},
+		{"explain empty text", http.MethodPost, "/v1/sentiment/explain", `{"text": "\t"}`, http.StatusBadRequest},
+		{"classify malformed", http.MethodPost, "/v1/classify", `{"model": 1}`, http.StatusBadRequest},
+		{"classify unknown model", http.MethodPost, "/v1/classify", `{"model": "events", "texts": ["a"]}`, http.StatusNotFound},
+		{"swap malformed", http.MethodPost, "/v1/admin/swap", `{"version": 2}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer secret")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d (body %v)", resp.StatusCode, tt.want, body)
+			}
+			if tt.want != http.StatusOK && body["error"] == nil {
+				t.Errorf("error response %v has no error message", body)
+			}
+		})
+	}
+}
+
+func TestHandleBatchPerItemErrors(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := http.Post(ts.URL+"/v1/sentiment/batch", "application/json", strings.NewReader(`{"texts": ["profit surged", " "]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Results []struct {
+			Label string `json:"label"`
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Results) != 2 || body.Results[0].Label != "positive" || body.Results[0].Error != "" || body.Results[1].Error != model.ErrEmptyText.Error() {
+		t.Errorf("results = %+v, want a positive result then an empty-text error", body.Results)
+	}
+}
@@ -0,0 +1,178 @@
+// Command sentimenteval measures sentiment models against a labelled dataset
+// so that model versions can be compared, for example:
+//
+//	go run ./cmd/sentimenteval -data data/headlines.csv -models finbert,finbert-fp32,lexicon -history eval/history.jsonl
+//
+// With -fit-calibration it instead fits a calibration for each model on the
+// dataset (a validation split, not the test set) and saves it at the path
+// the model definition names, where the analyzer picks it up on next start:
+//
+//	go run ./cmd/sentimenteval -data data/validation.csv -models finbert -fit-calibration temperature
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/Bhavik2205/ML-Bot/internal/model"
+)
+
+func main() {
+	dataPath := flag.String("data", "", "labelled dataset (.csv with text,label columns or .jsonl)")
+	configPath := flag.String("config", model.DefaultModelConfigPath, "model definitions file")
+	models := flag.String("models", "", "comma-separated models to evaluate (default model from the config if empty)")
+	long := flag.Bool("long", false, "score with sliding windows (AnalyzeLong)")
+	bins := flag.Int("bins", 10, "confidence bins for the calibration error")
+	jsonOut := flag.Bool("json", false, "print reports as JSON")
+	history := flag.String("history", "", "append one JSON line per report to this file")
+	fitMethod := flag.String("fit-calibration", "", "fit a calibration (temperature or platt) instead of evaluating")
+	calibrationOut := flag.String("calibration-out", "", "where to save the fitted calibration (default: the model's calibration path)")
+	flag.Parse()
+
+	if *dataPath == "" {
+		fmt.Println("Please pass a labelled dataset with -data.")
+		os.Exit(2)
+	}
+	// .env is optional here; it usually only provides ONNX_DLL_PATH.
+	_ = godotenv.Load()
+
+	cfg, err := model.LoadModelConfig(*configPath)
+	if err != nil {
+		fmt.Println("Error loading model config:", err)
+		os.Exit(1)
+	}
+	examples, err := model.LoadLabeledDataset(*dataPath)
+	if err != nil {
+		fmt.Println("Error loading dataset:", err)
+		os.Exit(1)
+	}
+
+	names := []string{cfg.Default}
+	if *models != "" {
+		names = strings.Split(*models, ",")
+	}
+
+	if *calibrationOut != "" && len(names) > 1 {
+		fmt.Println("-calibration-out can only be used with a single model.")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, name := range names {
+		if *fitMethod != "" {
+			if err := fitCalibration(cfg, strings.TrimSpace(name), examples, *fitMethod, *dataPath, *calibrationOut, *jsonOut); err != nil {
+				fmt.Printf("Error calibrating %s: %v\n", name, err)
+				failed = true
+			}
+			continue
+		}
+
+		report, err := evaluate(cfg, strings.TrimSpace(name), examples, model.EvalOptions{Dataset: *dataPath, Long: *long, Bins: *bins})
+		if err != nil {
+			fmt.Printf("Error evaluating %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+
+		if *jsonOut {
+			out, err := report.JSON()
+			if err != nil {
+				fmt.Println("Error encoding report:", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(out))
+		} else {
+			report.WriteText(os.Stdout)
+			fmt.Println()
+		}
+		if *history != "" {
+			if err := appendHistory(*history, report); err != nil {
+				fmt.Println("Error writing history:", err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// evaluate opens the model itself rather than through OpenSentimentAnalyzer:
+// a fallback model or a cached result would make the report lie about it.
+func evaluate(cfg *model.ModelConfig, name string, examples []model.LabeledExample, opts model.EvalOptions) (*model.EvalReport, error) {
+	def, err := cfg.Model(name)
+	if err != nil {
+		return nil, err
+	}
+	analyzer, err := cfg.NewAnalyzer(def)
+	if err != nil {
+		return nil, err
+	}
+	defer analyzer.Close()
+	return model.Evaluate(analyzer, examples, opts)
+}
+
+// fitCalibration fits the method on the model's raw probabilities and saves
+// the result, printing reliability before and after.
+func fitCalibration(cfg *model.ModelConfig, name string, examples []model.LabeledExample, method, dataset, out string, jsonOut bool) error {
+	def, err := cfg.Model(name)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		out = def.CalibrationPath
+	}
+	if out == "" {
+		return fmt.Errorf("model %q has no calibration path, pass -calibration-out", name)
+	}
+
+	def.Calibration = nil
+	analyzer, err := cfg.NewAnalyzer(def)
+	if err != nil {
+		return err
+	}
+	defer analyzer.Close()
+
+	calibration, err := model.FitCalibration(def, analyzer, examples, method)
+	if err != nil {
+		return err
+	}
+	calibration.Dataset = dataset
+	if err := calibration.Save(out); err != nil {
+		return err
+	}
+
+	if jsonOut {
+		raw, err := json.MarshalIndent(calibration, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+	} else {
+		fmt.Printf("Calibration for %s saved to %s\n", def.Name, out)
+		calibration.WriteText(os.Stdout)
+		fmt.Println()
+	}
+	return nil
+}
+
+func appendHistory(path string, report *model.EvalReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
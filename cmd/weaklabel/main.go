@@ -0,0 +1,150 @@
+// Command weaklabel builds a training set from archived news by labelling
+// each article with the price reaction of its ticker (see the weak_labeling
+// section of configs/model.yaml):
+//
+//	weaklabel -news data/news/archive.jsonl -prices data/prices -out data/weak
+//	weaklabel -horizons 1d,3d -label-horizon 1d -positive 0.03 -negative -0.03
+//
+// The archive is written by cmd/main.go -company X -archive. Prices are one
+// <SYMBOL>.csv per ticker plus the benchmark (NIFTY50.csv), for example
+// exported from Kite historical data. Archived company names are matched to
+// price files through their aliases in configs/entities.yaml, so Infosys
+// articles use INFY.csv. The output has train.csv and validation.csv for
+// fine-tuning with scripts/retrain_sentiment.py and examples.jsonl with the
+// returns at every horizon for a news-impact model.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Bhavik2205/ML-Bot/internal/model"
+)
+
+func main() {
+	configPath := flag.String("config", model.DefaultModelConfigPath, "model definitions file")
+	newsPath := flag.String("news", "data/news/archive.jsonl", "JSON lines article archive")
+	priceDir := flag.String("prices", "data/prices", "directory of <SYMBOL>.csv price files")
+	out := flag.String("out", "data/weak", "output directory")
+	validation := flag.Float64("validation", 0.2, "latest fraction of examples used for validation")
+	benchmark := flag.String("benchmark", "", "benchmark symbol (overrides the config)")
+	horizons := flag.String("horizons", "", "comma-separated horizons such as 1d,3d,4h (overrides the config)")
+	labelHorizon := flag.String("label-horizon", "", "horizon that decides the label (overrides the config)")
+	positive := flag.Float64("positive", 0, "excess return for a positive label (overrides the config)")
+	negative := flag.Float64("negative", 0, "excess return for a negative label (overrides the config)")
+	neutral := flag.Float64("neutral", 0, "neutral band around zero (overrides the config)")
+	entitiesPath := flag.String("entities", model.DefaultEntitiesPath, "company aliases used to find price files")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	cfg, err := model.LoadModelConfig(*configPath)
+	if err != nil {
+		fmt.Println("Error loading model config:", err)
+		os.Exit(1)
+	}
+	wcfg := cfg.WeakLabeling
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["benchmark"] {
+		wcfg.Benchmark = *benchmark
+	}
+	if set["horizons"] {
+		if wcfg.Horizons, err = model.ParseHorizons(*horizons); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		if !set["label-horizon"] {
+			wcfg.LabelHorizon = wcfg.Horizons[0]
+		}
+	}
+	if set["label-horizon"] {
+		h, err := model.ParseHorizons(*labelHorizon)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		wcfg.LabelHorizon = h[0]
+	}
+	if set["positive"] {
+		wcfg.Positive = *positive
+	}
+	if set["negative"] {
+		wcfg.Negative = *negative
+	}
+	if set["neutral"] {
+		wcfg.NeutralBand = *neutral
+	}
+	closeAt := wcfg.DailyCloseAt
+	if closeAt == "" {
+		closeAt = "15:30"
+	}
+
+	records, err := model.LoadNewsRecords(*newsPath)
+	if err != nil {
+		fmt.Println("Error loading news:", err)
+		os.Exit(1)
+	}
+	prices, err := model.LoadPriceDir(*priceDir, closeAt)
+	if err != nil {
+		fmt.Println("Error loading prices:", err)
+		os.Exit(1)
+	}
+
+	if entities, err := model.LoadEntities(*entitiesPath); err == nil {
+		resolveTickers(records, prices, entities)
+	}
+
+	dataset, err := model.BuildWeakDataset(records, prices, wcfg)
+	if err != nil {
+		fmt.Println("Error building dataset:", err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		raw, err := json.MarshalIndent(dataset, "", "  ")
+		if err != nil {
+			fmt.Println("Error encoding report:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(raw))
+	} else {
+		dataset.Report.WriteText(os.Stdout)
+	}
+
+	exp, err := dataset.Export(*out, *validation)
+	if err != nil {
+		fmt.Println("Error exporting dataset:", err)
+		os.Exit(1)
+	}
+	if !*asJSON {
+		fmt.Printf("Wrote %d training and %d validation examples to %s, returns to %s\n",
+			exp.TrainCount, exp.ValidationCount, *out, exp.Examples)
+	}
+}
+
+// resolveTickers replaces company names without a price file by the first
+// alias of the company that has one. Aliases qualified as NSE:<SYMBOL> name
+// the SYMBOL.csv file.
+func resolveTickers(records []model.NewsRecord, prices map[string]*model.PriceSeries, entities []model.Entity) {
+	resolve := func(name string) string {
+		if _, found := prices[strings.ToUpper(name)]; found {
+			return name
+		}
+		e := model.FindEntity(entities, name)
+		for _, alias := range append([]string{e.Name}, e.Aliases...) {
+			symbol := strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(alias, " ", "")), "NSE:")
+			if _, found := prices[symbol]; found {
+				return symbol
+			}
+		}
+		return name
+	}
+	for i := range records {
+		records[i].Ticker = resolve(records[i].Ticker)
+		for j, t := range records[i].Tickers {
+			records[i].Tickers[j] = resolve(t)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package data
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTrustedDomains scores how far a publisher can be trusted on its own.
+// Articles from domains not listed here have zero trust.
+var DefaultTrustedDomains = map[string]float64{
+	"reuters.com":                  1.0,
+	"bloomberg.com":                1.0,
+	"finance.yahoo.com":            0.9,
+	"livemint.com":                 0.9,
+	"economictimes.indiatimes.com": 0.9,
+	"business-standard.com":        0.9,
+	"moneycontrol.com":             0.85,
+	"thehindubusinessline.com":     0.85,
+	"financialexpress.com":         0.8,
+	"cnbctv18.com":                 0.8,
+	"ndtvprofit.com":               0.8,
+}
+
+// highImpactTerms are headline words that usually move a stock on their own.
+// Words that are as often used in other senses ("fine", "bid") score below
+// the corroborator's default MinImpact.
+var highImpactTerms = map[string]float64{
+	"fraud": 1, "raid": 1, "probe": 0.8, "default": 1, "bankruptcy": 1, "insolvency": 1,
+	"acquisition": 0.8, "acquire": 0.8, "acquires": 0.8, "merger": 0.8, "takeover": 0.9,
+	"resigns": 0.8, "resignation": 0.8, "arrested": 1, "sebi": 0.6, "ban": 0.8, "banned": 0.8,
+	"penalty": 0.6, "fine": 0.4, "downgrade": 0.7, "upgrade": 0.6, "halt": 0.8, "suspended": 0.8,
+	"plunge": 0.6, "plunges": 0.6, "crash": 0.7, "soars": 0.6, "surge": 0.5, "recall": 0.6,
+	"lawsuit": 0.6, "stake": 0.5, "buyback": 0.6, "delisting": 1, "scam": 1, "rumour": 0.6,
+	"rumor": 0.6, "bid": 0.4, "blocks": 0.4, "pledge": 0.6,
+}
+
+// Corroboration records whether an article's story was confirmed by trusted sources.
+type Corroboration struct {
+	SourceTrust float64  `json:"source_trust"`
+	Impact      float64  `json:"impact"`
+	Checked     bool     `json:"checked"` // false when the article didn't need checking
+	Score       float64  `json:"score"`   // 0..1, higher means better corroborated
+	MatchedURLs []string `json:"matched_urls,omitempty"`
+}
+
+// TrustedFeed is a source of trusted headlines used to corroborate articles.
+type TrustedFeed interface {
+	Name() string
+	Search(ctx context.Context, query string, from, to time.Time) ([]NewsArticle, error)
+}
+
+// Corroborator cross-checks high-impact articles from low-trust sources
+// against trusted feeds before they are allowed to drive trades.
+type Corroborator struct {
+	Feeds          []TrustedFeed
+	TrustedDomains map[string]float64
+	MinTrust       float64       // articles from sources at or above this trust are not checked
+	MinImpact      float64       // articles below this impact are not checked
+	Window         time.Duration // maximum publish time distance of a matching headline
+	MatchThreshold float64       // minimum headline similarity of a match
+}
+
+// NewCorroborator creates a corroborator with the Yahoo Finance and Reuters feeds.
+func NewCorroborator() *Corroborator {
+	return &Corroborator{
+		Feeds:          []TrustedFeed{YahooFinanceFeed{}, ReutersFeed{}},
+		TrustedDomains: DefaultTrustedDomains,
+		MinTrust:       0.8,
+		MinImpact:      0.5,
+		Window:         24 * time.Hour,
+		MatchThreshold: 0.3,
+	}
+}
+
+// Corroborate annotates articles in place. Trusted feeds are searched at most
+// once per call, covering the publish window of all articles needing a check.
+func (c *Corroborator) Corroborate(ctx context.Context, company string, articles []NewsArticle) error {
+	var pending []int
+	var from, to time.Time
+	for i := range articles {
+		a := &articles[i]
+		a.Corroboration = &Corroboration{
+			SourceTrust: c.SourceTrust(*a),
+			Impact:      ImpactScore(*a),
+		}
+		if a.Corroboration.SourceTrust >= c.MinTrust {
+			a.Corroboration.Score = a.Corroboration.SourceTrust
+			continue
+		}
+		if a.Corroboration.Impact < c.MinImpact {
+			continue
+		}
+		pending = append(pending, i)
+
+		t := a.PublishedAt
+		if t.IsZero() {
+			t = time.Now()
+		}
+		if from.IsZero() || t.Before(from) {
+			from = t
+		}
+		if t.After(to) {
+			to = t
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var trusted []NewsArticle
+	var errs []error
+	for _, feed := range c.Feeds {
+		found, err := feed.Search(ctx, company, from.Add(-c.Window), to.Add(c.Window))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", feed.Name(), err))
+			continue
+		}
+		trusted = append(trusted, found...)
+	}
+
+	for _, i := range pending {
+		c.match(&articles[i], trusted)
+	}
+	return errors.Join(errs...)
+}
+
+// match scores an article against trusted headlines. Each match contributes
+// its similarity weighted by the matching source's trust; contributions are
+// combined as independent evidence. Headlines from sources below MinTrust,
+// which feeds may pass through, don't count: one rumour site must not
+// confirm another.
+func (c *Corroborator) match(a *NewsArticle, trusted []NewsArticle) {
+	a.Corroboration.Checked = true
+	tokens := headlineTokens(a.Title)
+	miss := 1.0
+	for _, t := range trusted {
+		if !a.PublishedAt.IsZero() && !t.PublishedAt.IsZero() {
+			d := a.PublishedAt.Sub(t.PublishedAt)
+			if d > c.Window || -d > c.Window {
+				continue
+			}
+		}
+		sim := jaccard(tokens, headlineTokens(t.Title))
+		if sim < c.MatchThreshold {
+			continue
+		}
+		trust := c.SourceTrust(t)
+		if trust == 0 || trust < c.MinTrust {
+			continue
+		}
+		miss *= 1 - sim*trust
+		a.Corroboration.MatchedURLs = append(a.Corroboration.MatchedURLs, t.URL)
+	}
+	a.Corroboration.Score = 1 - miss
+}
+
+// SourceTrust returns the trust of the article's publisher, matching its URL
+// host or source name against the trusted domains.
+func (c *Corroborator) SourceTrust(a NewsArticle) float64 {
+	domains := c.TrustedDomains
+	if domains == nil {
+		domains = DefaultTrustedDomains
+	}
+	candidates := []string{normalizePublisher(a.Source)}
+	if u, err := url.Parse(a.URL); err == nil && u.Host != "" {
+		candidates = append(candidates, normalizePublisher(u.Host))
+	}
+	best := 0.0
+	for _, host := range candidates {
+		for domain, trust := range domains {
+			if (host == domain || strings.HasSuffix(host, "."+domain)) && trust > best {
+				best = trust
+			}
+		}
+	}
+	return best
+}
+
+// ImpactScore estimates how market-moving a headline is from its wording.
+func ImpactScore(a NewsArticle) float64 {
+	score := 0.0
+	for _, w := range strings.Fields(CleanText(a.Title)) {
+		if v, found := highImpactTerms[w]; found && v > score {
+			score = v
+		}
+	}
+	return score
+}
+
+// Uncorroborated reports whether the article needed checking and no trusted
+// source carried the story.
+func (c *Corroboration) Uncorroborated() bool {
+	return c != nil && c.Checked && len(c.MatchedURLs) == 0
+}
+
+// --- Trusted feeds ---
+
+// YahooFinanceFeed searches the Yahoo Finance headline RSS feed by ticker.
+type YahooFinanceFeed struct{}
+
+func (YahooFinanceFeed) Name() string { return "YahooFinance" }
+
+func (YahooFinanceFeed) Search(ctx context.Context, query string, from, to time.Time) ([]NewsArticle, error) {
+	u := fmt.Sprintf("https://feeds.finance.yahoo.com/rss/2.0/headline?s=%s&region=US&lang=en-US", url.QueryEscape(query))
+	return fetchRSS(ctx, "YahooFinance", "finance.yahoo.com", u, from, to)
+}
+
+// ReutersFeed searches Reuters coverage through the Google News RSS search,
+// since Reuters has no public API.
+type ReutersFeed struct{}
+
+func (ReutersFeed) Name() string { return "Reuters" }
+
+func (ReutersFeed) Search(ctx context.Context, query string, from, to time.Time) ([]NewsArticle, error) {
+	q := url.QueryEscape(query + " site:reuters.com")
+	u := fmt.Sprintf("https://news.google.com/rss/search?q=%s&hl=en-IN&gl=IN&ceid=IN:en", q)
+	return fetchRSS(ctx, "Reuters", "reuters.com", u, from, to)
+}
+
+// fetchRSS downloads an RSS 2.0 feed and returns items published within [from, to].
+// Items are attributed to the host of their <source> element, or defaultSource.
+// Google News appends " - <source>" to every title; it is removed so that
+// the publisher's name doesn't count as a shared headline word.
+func fetchRSS(ctx context.Context, source, defaultSource, feedURL string, from, to time.Time) ([]NewsArticle, error) {
+	rep := &SourceReport{Source: source}
+	body, err := doGetWithRetry(ctx, feedURL, rep)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			Source      struct {
+				URL  string `xml:"url,attr"`
+				Name string `xml:",chardata"`
+			} `xml:"source"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("%s RSS unmarshal failed: %w", source, err)
+	}
+
+	articles := make([]NewsArticle, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		t := parsePublishTime(source, item.PubDate)
+		if !t.IsZero() && (t.Before(from) || t.After(to)) {
+			continue
+		}
+		name := defaultSource
+		if u, err := url.Parse(item.Source.URL); err == nil && u.Host != "" {
+			name = u.Host
+		}
+		articles = append(articles, NewsArticle{
+			Source:      name,
+			Title:       stripPublisherSuffix(item.Title, item.Source.Name),
+			Description: item.Description,
+			URL:         item.Link,
+			PublishedAt: t,
+		})
+	}
+	return articles, nil
+}
+
+// stripPublisherSuffix removes a trailing " - publisher" from title.
+func stripPublisherSuffix(title, publisher string) string {
+	publisher = strings.TrimSpace(publisher)
+	if publisher == "" {
+		return title
+	}
+	if t, found := strings.CutSuffix(strings.TrimSpace(title), " - "+publisher); found {
+		return t
+	}
+	return title
+}
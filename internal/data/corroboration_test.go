@@ -0,0 +1,143 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type stubFeed []NewsArticle
+
+func (stubFeed) Name() string { return "stub" }
+
+func (f stubFeed) Search(ctx context.Context, query string, from, to time.Time) ([]NewsArticle, error) {
+	return f, nil
+}
+
+func TestCorroborate(t *testing.T) {
+	t0 := time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)
+	c := NewCorroborator()
+	c.Feeds = []TrustedFeed{stubFeed{
+		{Source: "reuters.com", Title: "Adani Ports to acquire Gopalpur port in $400 million deal", URL: "https://www.reuters.com/a", PublishedAt: t0.Add(time.Hour)},
+		// An untrusted site passed through by a feed must not confirm anything.
+		{Source: "rumourmill.example", Title: "SEBI raid at Zee Entertainment offices", URL: "https://rumourmill.example/b", PublishedAt: t0},
+	}}
+
+	articles := []NewsArticle{
+		{Source: "smallblog.example", Title: "Adani Ports to acquire Gopalpur port", URL: "https://smallblog.example/1", PublishedAt: t0},
+		{Source: "otherblog.example", Title: "SEBI raid at Zee Entertainment offices", URL: "https://otherblog.example/2", PublishedAt: t0},
+		{Source: "otherblog.example", Title: "Zee Entertainment quarterly results today", URL: "https://otherblog.example/3", PublishedAt: t0},
+		{Source: "livemint.com", Title: "Infosys fraud probe widens", URL: "https://www.livemint.com/4", PublishedAt: t0},
+	}
+	if err := c.Corroborate(context.Background(), "ADANIPORTS", articles); err != nil {
+		t.Fatal(err)
+	}
+
+	confirmed := articles[0].Corroboration
+	if !confirmed.Checked || confirmed.Uncorroborated() || confirmed.Score <= 0 || len(confirmed.MatchedURLs) != 1 {
+		t.Errorf("Reuters match not counted: %+v", confirmed)
+	}
+	if rumour := articles[1].Corroboration; !rumour.Uncorroborated() || rumour.Score != 0 {
+		t.Errorf("rumour confirmed by an untrusted source: %+v", rumour)
+	}
+	if lowImpact := articles[2].Corroboration; lowImpact.Checked {
+		t.Errorf("low-impact article was checked: %+v", lowImpact)
+	}
+	if trusted := articles[3].Corroboration; trusted.Checked || trusted.Score != 0.9 {
+		t.Errorf("trusted source was checked: %+v", trusted)
+	}
+}
+
+func TestSourceTrust(t *testing.T) {
+	c := NewCorroborator()
+	tests := []struct {
+		article NewsArticle
+		want    float64
+	}{
+		{NewsArticle{Source: "Reuters.com"}, 1},
+		{NewsArticle{Source: "Mint", URL: "https://www.livemint.com/x"}, 0.9},
+		{NewsArticle{Source: "markets.economictimes.indiatimes.com"}, 0.9},
+		{NewsArticle{Source: "notreuters.com"}, 0},
+	}
+	for _, tt := range tests {
+		if got := c.SourceTrust(tt.article); got != tt.want {
+			t.Errorf("SourceTrust(%+v) = %v, want %v", tt.article, got, tt.want)
+		}
+	}
+}
+
+func TestImpactScore(t *testing.T) {
+	tests := []struct {
+		title string
+		want  float64
+	}{
+		{"Zee Entertainment fraud probe widens", 1},
+		{"Adani Ports to acquire Gopalpur port", 0.8},
+		{"SEBI imposes fine on Yes Bank", 0.6},
+		// Common words in their other senses stay below the default MinImpact.
+		{"Infosys caps a fine quarter", 0.4},
+		{"Tata Power wins bid for Mumbai discom", 0.4},
+		{"Infosys quarterly results today", 0},
+	}
+	minImpact := NewCorroborator().MinImpact
+	for _, tt := range tests {
+		got := ImpactScore(NewsArticle{Title: tt.title})
+		if got != tt.want {
+			t.Errorf("ImpactScore(%q) = %v, want %v", tt.title, got, tt.want)
+		}
+		if tt.want == 0.4 && got >= minImpact {
+			t.Errorf("%q would be checked at MinImpact %v", tt.title, minImpact)
+		}
+	}
+}
+
+func TestStripPublisherSuffix(t *testing.T) {
+	tests := []struct {
+		title, publisher, want string
+	}{
+		{"Adani Ports to buy Gopalpur port - Reuters", "Reuters", "Adani Ports to buy Gopalpur port"},
+		{"Sensex falls 500 points - The Economic Times ", "The Economic Times", "Sensex falls 500 points"},
+		{"Mid-cap rally - analysts split", "Reuters", "Mid-cap rally - analysts split"},
+		{"Adani Ports to buy Gopalpur port - Reuters", "", "Adani Ports to buy Gopalpur port - Reuters"},
+	}
+	for _, tt := range tests {
+		if got := stripPublisherSuffix(tt.title, tt.publisher); got != tt.want {
+			t.Errorf("stripPublisherSuffix(%q, %q) = %q, want %q", tt.title, tt.publisher, got, tt.want)
+		}
+	}
+}
+
+func TestReutersFeedMatchesWithoutPublisherSuffix(t *testing.T) {
+	useStubSources(t, stubSources{
+		"news.google.com": respond(http.StatusOK, nil, `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<item>
+  <title>Adani Ports to acquire Gopalpur Port - Reuters</title>
+  <link>https://news.google.com/rss/articles/abc</link>
+  <pubDate>Fri, 16 Oct 2026 06:00:00 GMT</pubDate>
+  <source url="https://www.reuters.com">Reuters</source>
+</item>
+</channel></rss>`),
+	})
+	t0 := time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)
+
+	found, err := ReutersFeed{}.Search(context.Background(), "Adani Ports", t0.Add(-time.Hour), t0.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Title != "Adani Ports to acquire Gopalpur Port" || found[0].Source != "www.reuters.com" {
+		t.Fatalf("found = %+v", found)
+	}
+
+	c := NewCorroborator()
+	c.Feeds = []TrustedFeed{ReutersFeed{}}
+	articles := []NewsArticle{{Source: "smallblog.example", Title: "Adani Ports to acquire Gopalpur Port", URL: "https://smallblog.example/1", PublishedAt: t0}}
+	if err := c.Corroborate(context.Background(), "Adani Ports", articles); err != nil {
+		t.Fatal(err)
+	}
+	// An identical headline matches with similarity 1, as the suffix is gone.
+	if got := articles[0].Corroboration; len(got.MatchedURLs) != 1 || got.Score != 1 {
+		t.Errorf("corroboration = %+v, want one full-strength Reuters match", got)
+	}
+}
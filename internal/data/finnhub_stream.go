@@ -0,0 +1,342 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultFinnhubStreamURL = "wss://ws.finnhub.io"
+
+var (
+	newsStreamMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "news_stream_messages_total",
+			Help: "Total number of websocket messages received per source and message type",
+		},
+		[]string{"source", "type"},
+	)
+	newsStreamReconnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "news_stream_reconnects_total",
+			Help: "Total number of websocket reconnect attempts per source",
+		},
+		[]string{"source"},
+	)
+	newsStreamDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "news_stream_dropped_total",
+			Help: "Total number of streamed articles dropped because the handler fell behind, per source",
+		},
+		[]string{"source"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(newsStreamMessages, newsStreamReconnects, newsStreamDropped)
+}
+
+// FinnhubStreamConfig configures the push-based Finnhub news source.
+type FinnhubStreamConfig struct {
+	URL          string        // websocket endpoint, defaults to wss://ws.finnhub.io
+	Token        string        // API token, defaults to FINNHUB_API_KEY
+	Symbols      []string      // symbols subscribed on every (re)connect
+	PingInterval time.Duration // interval between heartbeat pings
+	ReadTimeout  time.Duration // connection is considered dead after this long without traffic
+	MinBackoff   time.Duration // first reconnect delay
+	MaxBackoff   time.Duration // reconnect delay cap
+	DedupSize    int           // number of recent article keys remembered for deduplication
+	QueueSize    int           // articles waiting for the handler before new ones are dropped
+}
+
+// FinnhubStream maintains a websocket subscription per symbol against the
+// Finnhub news feed and hands every new, deduplicated article to a handler.
+type FinnhubStream struct {
+	cfg    FinnhubStreamConfig
+	dialer *websocket.Dialer
+	dedup  *ArticleDeduper
+
+	mu      sync.Mutex
+	symbols map[string]struct{}
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// NewFinnhubStream creates a stream with defaults filled in for any unset option.
+func NewFinnhubStream(cfg FinnhubStreamConfig) *FinnhubStream {
+	if cfg.URL == "" {
+		cfg.URL = defaultFinnhubStreamURL
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("FINNHUB_API_KEY")
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 20 * time.Second
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 3 * cfg.PingInterval
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.DedupSize <= 0 {
+		cfg.DedupSize = 10000
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+
+	s := &FinnhubStream{
+		cfg:     cfg,
+		dialer:  &websocket.Dialer{HandshakeTimeout: client.Timeout},
+		dedup:   NewArticleDeduper(cfg.DedupSize),
+		symbols: make(map[string]struct{}),
+	}
+	for _, sym := range cfg.Symbols {
+		s.symbols[strings.ToUpper(sym)] = struct{}{}
+	}
+	return s
+}
+
+// Subscribe adds a symbol to the stream, sending the subscription immediately if connected.
+func (s *FinnhubStream) Subscribe(symbol string) error {
+	symbol = strings.ToUpper(symbol)
+	s.mu.Lock()
+	s.symbols[symbol] = struct{}{}
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return s.send(conn, "subscribe-news", symbol)
+}
+
+// Unsubscribe removes a symbol from the stream.
+func (s *FinnhubStream) Unsubscribe(symbol string) error {
+	symbol = strings.ToUpper(symbol)
+	s.mu.Lock()
+	delete(s.symbols, symbol)
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return s.send(conn, "unsubscribe-news", symbol)
+}
+
+// Run connects to Finnhub and delivers articles to handle until ctx is cancelled,
+// reconnecting with exponential backoff whenever the connection drops.
+//
+// handle runs on its own goroutine, one article at a time, so a slow handler
+// doesn't stall reads and heartbeats into a disconnect. Articles arriving
+// while QueueSize others wait for it are dropped and counted. Run returns
+// once the article being handled, if any, is done; articles still queued
+// when ctx ends are not handled.
+func (s *FinnhubStream) Run(ctx context.Context, handle func(NewsArticle)) error {
+	if s.cfg.Token == "" {
+		return errors.New("FINNHUB_API_KEY not set")
+	}
+
+	queue := make(chan NewsArticle, s.cfg.QueueSize)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for a := range queue {
+			if ctx.Err() == nil {
+				handle(a)
+			}
+		}
+	}()
+	defer func() {
+		close(queue)
+		wg.Wait()
+	}()
+
+	backoff := s.cfg.MinBackoff
+	for {
+		start := time.Now()
+		err := s.runOnce(ctx, queue)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		newsFetchErrors.WithLabelValues("FinnhubStream").Inc()
+		newsStreamReconnects.WithLabelValues("FinnhubStream").Inc()
+
+		// A connection that stayed healthy for a while resets the backoff.
+		if time.Since(start) > s.cfg.MaxBackoff {
+			backoff = s.cfg.MinBackoff
+		}
+		logger.Warnw("Finnhub stream disconnected, reconnecting", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.cfg.MaxBackoff {
+			backoff = s.cfg.MaxBackoff
+		}
+	}
+}
+
+// runOnce dials, subscribes and reads until the connection fails or ctx is
+// done, queueing new articles for Run's handler.
+func (s *FinnhubStream) runOnce(ctx context.Context, queue chan<- NewsArticle) error {
+	u, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid Finnhub stream URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("token", s.cfg.Token)
+	u.RawQuery = q.Encode()
+
+	newsFetchCount.WithLabelValues("FinnhubStream").Inc()
+	conn, _, err := s.dialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.conn = conn
+	symbols := make([]string, 0, len(s.symbols))
+	for sym := range s.symbols {
+		symbols = append(symbols, sym)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
+	for _, sym := range symbols {
+		if err := s.send(conn, "subscribe-news", sym); err != nil {
+			return fmt.Errorf("subscribe %s failed: %w", sym, err)
+		}
+	}
+	logger.Infow("Finnhub stream connected", "symbols", symbols)
+
+	conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+	})
+
+	// Heartbeat: ping periodically and close the connection when ctx ends so
+	// the blocking read below returns.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(s.cfg.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				s.writeMu.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+				s.writeMu.Unlock()
+				if err != nil {
+					logger.Warnw("Finnhub stream ping failed", "error", err)
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+
+		articles, err := s.parseMessage(msg)
+		if err != nil {
+			logger.Warnw("Failed to parse Finnhub stream message", "error", err, "message", string(msg))
+			continue
+		}
+		for _, a := range articles {
+			if s.dedup.Seen(a) {
+				continue
+			}
+			select {
+			case queue <- a:
+			default:
+				newsStreamDropped.WithLabelValues("FinnhubStream").Inc()
+				logger.Warnw("Finnhub stream handler is behind, dropping article", "title", a.Title, "queued", len(queue))
+			}
+		}
+	}
+}
+
+func (s *FinnhubStream) send(conn *websocket.Conn, msgType, symbol string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return conn.WriteJSON(map[string]string{"type": msgType, "symbol": symbol})
+}
+
+// parseMessage normalizes a Finnhub websocket frame into articles. Pings and
+// other control frames yield no articles.
+func (s *FinnhubStream) parseMessage(msg []byte) ([]NewsArticle, error) {
+	var frame struct {
+		Type string `json:"type"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Headline string `json:"headline"`
+			Source   string `json:"source"`
+			URL      string `json:"url"`
+			Datetime int64  `json:"datetime"` // unix timestamp
+			Summary  string `json:"summary"`
+			Related  string `json:"related"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(msg, &frame); err != nil {
+		return nil, err
+	}
+	newsStreamMessages.WithLabelValues("FinnhubStream", frame.Type).Inc()
+
+	switch frame.Type {
+	case "news":
+	case "error":
+		return nil, fmt.Errorf("Finnhub stream error: %s", frame.Msg)
+	default:
+		return nil, nil
+	}
+
+	articles := make([]NewsArticle, 0, len(frame.Data))
+	for _, item := range frame.Data {
+		a := NewsArticle{
+			Source:      item.Source,
+			Title:       item.Headline,
+			Description: item.Summary,
+			URL:         item.URL,
+			PublishedAt: unixTime(item.Datetime),
+		}
+		normalizeArticle(&a)
+		articles = append(articles, a)
+	}
+	return articles, nil
+}
@@ -0,0 +1,305 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeFinnhub is a local stand-in for wss://ws.finnhub.io. Each connection
+// is handed to the test through conns after the upgrade.
+type fakeFinnhub struct {
+	t     *testing.T
+	srv   *httptest.Server
+	conns chan *fakeConn
+}
+
+type fakeConn struct {
+	conn  *websocket.Conn
+	token string
+	subs  chan string // symbols subscribed, in order
+	pings chan struct{}
+	pongs chan struct{} // answers to the server's pings
+}
+
+func newFakeFinnhub(t *testing.T) *fakeFinnhub {
+	f := &fakeFinnhub{t: t, conns: make(chan *fakeConn, 4)}
+	upgrader := websocket.Upgrader{}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		fc := &fakeConn{
+			conn:  conn,
+			token: r.URL.Query().Get("token"),
+			subs:  make(chan string, 16),
+			pings: make(chan struct{}, 16),
+			pongs: make(chan struct{}, 16),
+		}
+		conn.SetPingHandler(func(data string) error {
+			select {
+			case fc.pings <- struct{}{}:
+			default:
+			}
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		conn.SetPongHandler(func(string) error {
+			fc.pongs <- struct{}{}
+			return nil
+		})
+		f.conns <- fc
+		// Read subscriptions until the client or the test closes the connection.
+		go func() {
+			for {
+				var msg map[string]string
+				if err := conn.ReadJSON(&msg); err != nil {
+					return
+				}
+				if msg["type"] == "subscribe-news" {
+					fc.subs <- msg["symbol"]
+				}
+			}
+		}()
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeFinnhub) url() string {
+	return "ws" + strings.TrimPrefix(f.srv.URL, "http")
+}
+
+func (f *fakeFinnhub) accept() *fakeConn {
+	f.t.Helper()
+	select {
+	case c := <-f.conns:
+		return c
+	case <-time.After(5 * time.Second):
+		f.t.Fatal("stream did not connect")
+		return nil
+	}
+}
+
+func (c *fakeConn) expectSubscribe(t *testing.T, symbol string) {
+	t.Helper()
+	select {
+	case got := <-c.subs:
+		if got != symbol {
+			t.Fatalf("subscribed to %q, want %q", got, symbol)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no subscription for %s", symbol)
+	}
+}
+
+func (c *fakeConn) sendNews(t *testing.T, headline, url string, at time.Time) {
+	t.Helper()
+	frame := fmt.Sprintf(`{"type":"news","data":[{"headline":%q,"source":"Reuters","url":%q,"datetime":%d,"summary":"","related":"INFY"}]}`,
+		headline, url, at.Unix())
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatalf("write news: %v", err)
+	}
+}
+
+func startStream(t *testing.T, f *fakeFinnhub, cfg FinnhubStreamConfig) (*FinnhubStream, chan NewsArticle) {
+	t.Helper()
+	articles := make(chan NewsArticle, 16)
+	return startStreamWith(t, f, cfg, func(a NewsArticle) { articles <- a }), articles
+}
+
+// startStreamWith runs a stream against f with handle until the test ends.
+func startStreamWith(t *testing.T, f *fakeFinnhub, cfg FinnhubStreamConfig, handle func(NewsArticle)) *FinnhubStream {
+	t.Helper()
+	cfg.URL = f.url()
+	cfg.Token = "test-token"
+	s := NewFinnhubStream(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Run(ctx, handle)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return s
+}
+
+func receive(t *testing.T, articles chan NewsArticle) NewsArticle {
+	t.Helper()
+	select {
+	case a := <-articles:
+		return a
+	case <-time.After(5 * time.Second):
+		t.Fatal("no article delivered")
+		return NewsArticle{}
+	}
+}
+
+func TestFinnhubStreamSubscribeAndDispatch(t *testing.T) {
+	f := newFakeFinnhub(t)
+	s, articles := startStream(t, f, FinnhubStreamConfig{Symbols: []string{"infy"}})
+
+	conn := f.accept()
+	if conn.token != "test-token" {
+		t.Errorf("token = %q, want test-token", conn.token)
+	}
+	conn.expectSubscribe(t, "INFY")
+
+	if err := s.Subscribe("tcs"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	conn.expectSubscribe(t, "TCS")
+
+	at := time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)
+	conn.sendNews(t, "Infosys wins large deal", "https://example.com/a", at)
+	a := receive(t, articles)
+	if a.Title != "Infosys wins large deal" || a.Source != "Reuters" || !a.PublishedAt.Equal(at) {
+		t.Errorf("unexpected article %+v", a)
+	}
+	if a.PublishedAt.Location() != time.UTC {
+		t.Errorf("PublishedAt is %s, want UTC", a.PublishedAt.Location())
+	}
+
+	// The same article again is deduplicated; a new one is delivered.
+	conn.sendNews(t, "Infosys wins large deal", "https://example.com/a", at)
+	conn.sendNews(t, "TCS results beat estimates", "https://example.com/b", at)
+	if a := receive(t, articles); a.Title != "TCS results beat estimates" {
+		t.Errorf("got %q, want the second article (duplicate not filtered)", a.Title)
+	}
+}
+
+func TestFinnhubStreamPing(t *testing.T) {
+	f := newFakeFinnhub(t)
+	startStream(t, f, FinnhubStreamConfig{Symbols: []string{"INFY"}, PingInterval: 20 * time.Millisecond})
+
+	conn := f.accept()
+	conn.expectSubscribe(t, "INFY")
+	for i := 0; i < 2; i++ {
+		select {
+		case <-conn.pings:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ping %d not received", i+1)
+		}
+	}
+}
+
+func TestFinnhubStreamReconnects(t *testing.T) {
+	f := newFakeFinnhub(t)
+	_, articles := startStream(t, f, FinnhubStreamConfig{
+		Symbols:    []string{"INFY"},
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 50 * time.Millisecond,
+	})
+
+	first := f.accept()
+	first.expectSubscribe(t, "INFY")
+	first.sendNews(t, "Before the drop", "https://example.com/1", time.Now())
+	receive(t, articles)
+	first.conn.Close()
+
+	// The stream reconnects and subscribes again; articles keep flowing.
+	second := f.accept()
+	second.expectSubscribe(t, "INFY")
+	second.sendNews(t, "After the drop", "https://example.com/2", time.Now())
+	if a := receive(t, articles); a.Title != "After the drop" {
+		t.Errorf("got %q after reconnect", a.Title)
+	}
+}
+
+func TestFinnhubStreamSlowHandler(t *testing.T) {
+	f := newFakeFinnhub(t)
+	release := make(chan struct{})
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	defer unblock()
+
+	articles := make(chan NewsArticle, 16)
+	startStreamWith(t, f, FinnhubStreamConfig{Symbols: []string{"INFY"}, QueueSize: 1}, func(a NewsArticle) {
+		articles <- a
+		<-release
+	})
+
+	conn := f.accept()
+	conn.expectSubscribe(t, "INFY")
+	at := time.Now()
+	conn.sendNews(t, "First", "https://example.com/1", at)
+	if a := receive(t, articles); a.Title != "First" {
+		t.Fatalf("got %q, want the first article", a.Title)
+	}
+
+	// The handler is stuck on the first article: the second waits in the
+	// queue, the third is dropped, and the stream keeps reading.
+	conn.sendNews(t, "Second", "https://example.com/2", at)
+	conn.sendNews(t, "Third", "https://example.com/3", at)
+	if err := conn.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-conn.pongs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream stopped reading while the handler was busy")
+	}
+
+	unblock()
+	conn.sendNews(t, "Fourth", "https://example.com/4", at)
+	for _, want := range []string{"Second", "Fourth"} {
+		if a := receive(t, articles); a.Title != want {
+			t.Errorf("got %q, want %q", a.Title, want)
+		}
+	}
+}
+
+func TestFinnhubStreamParseMessage(t *testing.T) {
+	s := NewFinnhubStream(FinnhubStreamConfig{Token: "test-token"})
+	at := time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		frame   string
+		want    []NewsArticle
+		wantErr bool
+	}{
+		{"ping", `{"type":"ping"}`, nil, false},
+		{"error frame", `{"type":"error","msg":"Invalid symbol"}`, nil, true},
+		{"malformed", `{"type":`, nil, true},
+		{
+			name:  "news",
+			frame: fmt.Sprintf(`{"type":"news","data":[{"headline":"Infosys wins deal","source":"Reuters","url":"https://example.com/a","datetime":%d,"summary":"Large deal"}]}`, at.Unix()),
+			want:  []NewsArticle{{Source: "Reuters", Title: "Infosys wins deal", Description: "Large deal", URL: "https://example.com/a", PublishedAt: at, Session: SessionMarket}},
+		},
+		{
+			name:  "unknown publish time",
+			frame: `{"type":"news","data":[{"headline":"Infosys wins deal","source":"Reuters","url":"https://example.com/a","datetime":0}]}`,
+			want:  []NewsArticle{{Source: "Reuters", Title: "Infosys wins deal", URL: "https://example.com/a", Session: SessionUnknown}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.parseMessage([]byte(tt.frame))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d articles, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("article %d = %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
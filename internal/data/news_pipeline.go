@@ -47,13 +47,6 @@ func init() {
 	// Register Prometheus metrics
 	prometheus.MustRegister(newsFetchCount, newsFetchErrors, newsFetchDuration)
 
-	// Initialize zap logger (production config)
-	l, err := zap.NewProduction()
-	if err != nil {
-		panic(fmt.Sprintf("failed to initialize logger: %v", err))
-	}
-	logger = l.Sugar()
-
 	// Initialize HTTP client with timeout
 	client = &http.Client{
 		Timeout: 10 * time.Second,
@@ -94,11 +87,14 @@ func validateAPIKeys() {
 
 // NewsArticle represents a normalized structure for news from any source
 type NewsArticle struct {
-	Source      string    `json:"source"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	URL         string    `json:"url"`
-	PublishedAt time.Time `json:"published_at"`
+	Source      string        `json:"source"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	URL         string        `json:"url"`
+	PublishedAt time.Time     `json:"published_at"` // always UTC; see PublishedIST
+	Session     MarketSession `json:"session"`
+
+	Corroboration *Corroboration `json:"corroboration,omitempty"`
 }
 
 // NewsPipelineConfig defines dynamic config options for each news source
@@ -116,16 +112,50 @@ type NewsPipelineConfig struct {
 	SkipFinnhub    bool
 	SkipEODHD      bool
 	SkipGoogleCSE  bool
+
+	// MaxAge drops articles published longer ago than this. Zero disables the filter.
+	MaxAge time.Duration
+
+	// Corroborator, when set, cross-checks high-impact articles from
+	// low-trust sources against trusted feeds.
+	Corroborator *Corroborator
+
+	// Stories, when set, receives every unique article so that callers can
+	// query story-level coverage across pipeline runs.
+	Stories *StoryClusterer
 }
 
 var (
 	config *NewsPipelineConfig
-	logger *zap.SugaredLogger
+	logger = newLogger() // set before any init function runs, in whichever file
 	client *http.Client
 )
 
+// newLogger initializes the zap logger (production config).
+func newLogger() *zap.SugaredLogger {
+	l, err := zap.NewProduction()
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize logger: %v", err))
+	}
+	return l.Sugar()
+}
+
+// DefaultNewsPipelineConfig returns the source settings used when
+// RunNewsPipeline is called with a nil config, for callers that want to add
+// a Corroborator, Stories or MaxAge to them.
+func DefaultNewsPipelineConfig() NewsPipelineConfig {
+	return *config
+}
+
 // RunNewsPipeline fetches news concurrently from multiple sources, aggregates, deduplicates and returns unique articles.
 func RunNewsPipeline(ctx context.Context, company string, cfg *NewsPipelineConfig) ([]NewsArticle, error) {
+	report, err := RunNewsPipelineReport(ctx, company, cfg)
+	return report.Articles, err
+}
+
+// RunNewsPipelineReport runs the pipeline like RunNewsPipeline and also returns
+// per-source fetch statistics and dedup/filter counts.
+func RunNewsPipelineReport(ctx context.Context, company string, cfg *NewsPipelineConfig) (*PipelineReport, error) {
 	if cfg == nil {
 		cfg = config
 	}
@@ -135,6 +165,11 @@ func RunNewsPipeline(ctx context.Context, company string, cfg *NewsPipelineConfi
 	// Clone config to avoid mutating passed config with limit decrement
 	cfgCopy := *cfg
 
+	report := &PipelineReport{
+		Company:   company,
+		StartedAt: time.Now(),
+	}
+
 	var (
 		mu          sync.Mutex
 		allArticles []NewsArticle
@@ -147,7 +182,7 @@ func RunNewsPipeline(ctx context.Context, company string, cfg *NewsPipelineConfi
 		use   bool
 		skip  bool
 		limit int
-		fetch func(context.Context, string, *NewsPipelineConfig) ([]NewsArticle, error)
+		fetch func(context.Context, string, *NewsPipelineConfig, *SourceReport) ([]NewsArticle, error)
 	}{
 		{"Marketaux", cfgCopy.UseMarketaux, cfgCopy.SkipMarketaux, cfgCopy.MarketauxLimit, fetchFromMarketaux},
 		{"Finnhub", cfgCopy.UseFinnhub, cfgCopy.SkipFinnhub, cfgCopy.FinnhubLimit, fetchFromFinnhub},
@@ -155,22 +190,26 @@ func RunNewsPipeline(ctx context.Context, company string, cfg *NewsPipelineConfi
 		{"GoogleCSE", cfgCopy.UseGoogleCSE, cfgCopy.SkipGoogleCSE, cfgCopy.GoogleCSELimit, fetchFromGoogleCSE},
 	}
 
-	for _, fetcher := range fetchers {
+	report.Sources = make([]SourceReport, len(fetchers))
+	for i, fetcher := range fetchers {
+		report.Sources[i] = SourceReport{Source: fetcher.name, QuotaRemaining: -1}
 		if !fetcher.use || fetcher.skip || fetcher.limit <= 0 {
 			logger.Debugw("Skipping source", "source", fetcher.name)
+			report.Sources[i].Skipped = true
 			continue
 		}
 
 		wg.Add(1)
-		go func(name string, fetch func(context.Context, string, *NewsPipelineConfig) ([]NewsArticle, error)) {
+		go func(name string, rep *SourceReport, fetch func(context.Context, string, *NewsPipelineConfig, *SourceReport) ([]NewsArticle, error)) {
 			defer wg.Done()
 
 			start := time.Now()
 			newsFetchCount.WithLabelValues(name).Inc()
 
-			articles, err := fetch(ctx, company, &cfgCopy)
+			articles, err := fetch(ctx, company, &cfgCopy, rep)
 			duration := time.Since(start).Seconds()
 			newsFetchDuration.WithLabelValues(name).Observe(duration)
+			rep.LatencySeconds = duration
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -178,26 +217,77 @@ func RunNewsPipeline(ctx context.Context, company string, cfg *NewsPipelineConfi
 			if err != nil {
 				newsFetchErrors.WithLabelValues(name).Inc()
 				logger.Errorw("Error fetching news", "source", name, "error", err)
+				rep.Error = err.Error()
 				errs = append(errs, fmt.Errorf("%s: %w", name, err))
 				return
 			}
 
 			logger.Infow("Fetched articles", "source", name, "count", len(articles), "duration_sec", duration)
+			rep.Articles = len(articles)
+			for i := range articles {
+				normalizeArticle(&articles[i])
+			}
 			allArticles = append(allArticles, articles...)
-		}(fetcher.name, fetcher.fetch)
+		}(fetcher.name, &report.Sources[i], fetcher.fetch)
 	}
 
 	wg.Wait()
 
+	report.Fetched = len(allArticles)
 	uniqueArticles := deduplicateArticles(allArticles)
-	logger.Infow("Pipeline complete", "unique_articles_count", len(uniqueArticles))
+	report.Duplicates = report.Fetched - len(uniqueArticles)
+
+	filtered := filterArticles(uniqueArticles, &cfgCopy)
+	report.Filtered = len(uniqueArticles) - len(filtered)
+	uniqueArticles = filtered
+
+	if cfgCopy.Corroborator != nil {
+		if err := cfgCopy.Corroborator.Corroborate(ctx, company, uniqueArticles); err != nil {
+			logger.Warnw("Corroboration incomplete", "error", err)
+		}
+		for _, a := range uniqueArticles {
+			if a.Corroboration.Checked {
+				report.CorroborationChecked++
+			}
+			if a.Corroboration.Uncorroborated() {
+				report.Uncorroborated++
+			}
+		}
+	}
+
+	report.Articles = uniqueArticles
+	report.Unique = len(uniqueArticles)
+	report.DurationSeconds = time.Since(report.StartedAt).Seconds()
+	logger.Infow("Pipeline complete", "unique_articles_count", len(uniqueArticles),
+		"duplicates", report.Duplicates, "filtered", report.Filtered)
+
+	if cfgCopy.Stories != nil {
+		cfgCopy.Stories.AddAll(uniqueArticles)
+	}
 
-	return uniqueArticles, errors.Join(errs...)
+	return report, errors.Join(errs...)
+}
+
+// filterArticles drops articles older than cfg.MaxAge. Articles without a
+// publish time are kept since their age is unknown.
+func filterArticles(articles []NewsArticle, cfg *NewsPipelineConfig) []NewsArticle {
+	if cfg.MaxAge <= 0 {
+		return articles
+	}
+	cutoff := time.Now().Add(-cfg.MaxAge)
+	result := make([]NewsArticle, 0, len(articles))
+	for _, a := range articles {
+		if !a.PublishedAt.IsZero() && a.PublishedAt.Before(cutoff) {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
 }
 
 // --- Fetch implementations ---
 
-func fetchFromMarketaux(ctx context.Context, company string, cfg *NewsPipelineConfig) ([]NewsArticle, error) {
+func fetchFromMarketaux(ctx context.Context, company string, cfg *NewsPipelineConfig, rep *SourceReport) ([]NewsArticle, error) {
 	if cfg.MarketauxLimit <= 0 {
 		return nil, errors.New("marketaux limit reached")
 	}
@@ -208,7 +298,7 @@ func fetchFromMarketaux(ctx context.Context, company string, cfg *NewsPipelineCo
 	}
 	url := fmt.Sprintf("https://api.marketaux.com/v1/news/all?filter_entities=true&entities=%s&api_token=%s", company, apiKey)
 
-	body, err := doGetWithRetry(ctx, url)
+	body, err := doGetWithRetry(ctx, url, rep)
 	if err != nil {
 		return nil, err
 	}
@@ -228,11 +318,7 @@ func fetchFromMarketaux(ctx context.Context, company string, cfg *NewsPipelineCo
 
 	articles := make([]NewsArticle, 0, len(resp.Data))
 	for _, item := range resp.Data {
-		t, err := time.Parse(time.RFC3339, item.PublishedAt)
-		if err != nil {
-			logger.Warnw("Failed to parse Marketaux published_at", "value", item.PublishedAt, "error", err)
-			t = time.Time{}
-		}
+		t := parsePublishTime("Marketaux", item.PublishedAt)
 		articles = append(articles, NewsArticle{
 			Source:      item.Source,
 			Title:       item.Title,
@@ -244,7 +330,7 @@ func fetchFromMarketaux(ctx context.Context, company string, cfg *NewsPipelineCo
 	return articles, nil
 }
 
-func fetchFromFinnhub(ctx context.Context, company string, cfg *NewsPipelineConfig) ([]NewsArticle, error) {
+func fetchFromFinnhub(ctx context.Context, company string, cfg *NewsPipelineConfig, rep *SourceReport) ([]NewsArticle, error) {
 	if cfg.FinnhubLimit <= 0 {
 		return nil, errors.New("finnhub limit reached")
 	}
@@ -257,7 +343,7 @@ func fetchFromFinnhub(ctx context.Context, company string, cfg *NewsPipelineConf
 	to := time.Now().Format("2006-01-02")
 	url := fmt.Sprintf("https://finnhub.io/api/v1/company-news?symbol=%s&from=%s&to=%s&token=%s", company, from, to, apiKey)
 
-	body, err := doGetWithRetry(ctx, url)
+	body, err := doGetWithRetry(ctx, url, rep)
 	if err != nil {
 		return nil, err
 	}
@@ -276,7 +362,7 @@ func fetchFromFinnhub(ctx context.Context, company string, cfg *NewsPipelineConf
 
 	articles := make([]NewsArticle, 0, len(resp))
 	for _, item := range resp {
-		t := time.Unix(item.Datetime, 0)
+		t := unixTime(item.Datetime)
 		articles = append(articles, NewsArticle{
 			Source:      item.Source,
 			Title:       item.Headline,
@@ -289,7 +375,7 @@ func fetchFromFinnhub(ctx context.Context, company string, cfg *NewsPipelineConf
 	return articles, nil
 }
 
-func fetchFromEODHD(ctx context.Context, company string, cfg *NewsPipelineConfig) ([]NewsArticle, error) {
+func fetchFromEODHD(ctx context.Context, company string, cfg *NewsPipelineConfig, rep *SourceReport) ([]NewsArticle, error) {
 	if cfg.EODHDLimit <= 0 {
 		return nil, errors.New("eodhd limit reached")
 	}
@@ -300,7 +386,7 @@ func fetchFromEODHD(ctx context.Context, company string, cfg *NewsPipelineConfig
 
 	url := fmt.Sprintf("https://eodhistoricaldata.com/api/news?api_token=%s&symbols=%s&period=d&limit=%d", apiKey, company, cfg.EODHDLimit)
 
-	body, err := doGetWithRetry(ctx, url)
+	body, err := doGetWithRetry(ctx, url, rep)
 	if err != nil {
 		return nil, err
 	}
@@ -318,11 +404,7 @@ func fetchFromEODHD(ctx context.Context, company string, cfg *NewsPipelineConfig
 
 	articles := make([]NewsArticle, 0, len(resp))
 	for _, item := range resp {
-		t, err := time.Parse(time.RFC3339, item.PubDate)
-		if err != nil {
-			logger.Warnw("Failed to parse EODHD published_at", "value", item.PubDate, "error", err)
-			t = time.Time{}
-		}
+		t := parsePublishTime("EODHD", item.PubDate)
 		articles = append(articles, NewsArticle{
 			Source:      item.Source,
 			Title:       item.Title,
@@ -335,7 +417,7 @@ func fetchFromEODHD(ctx context.Context, company string, cfg *NewsPipelineConfig
 	return articles, nil
 }
 
-func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineConfig) ([]NewsArticle, error) {
+func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineConfig, rep *SourceReport) ([]NewsArticle, error) {
 	if cfg.GoogleCSELimit <= 0 {
 		return nil, errors.New("google cse limit reached")
 	}
@@ -348,7 +430,7 @@ func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineCo
 
 	url := fmt.Sprintf("https://www.googleapis.com/customsearch/v1?q=%s&cx=%s&key=%s&num=%d&sort=date", company, cseID, apiKey, cfg.GoogleCSELimit)
 
-	body, err := doGetWithRetry(ctx, url)
+	body, err := doGetWithRetry(ctx, url, rep)
 	if err != nil {
 		return nil, err
 	}
@@ -363,6 +445,9 @@ func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineCo
 			Pagemap       struct {
 				Metatags []struct {
 					ArticlePublishedTime string `json:"article:published_time"`
+					OGPublishedTime      string `json:"og:published_time"`
+					DatePublished        string `json:"datepublished"`
+					PubDate              string `json:"pubdate"`
 				} `json:"metatags"`
 			} `json:"pagemap"`
 		} `json:"items"`
@@ -377,17 +462,16 @@ func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineCo
 		var publishedAt time.Time
 
 		// Try metatags first for published_time
-		if len(item.Pagemap.Metatags) > 0 {
-			pt := item.Pagemap.Metatags[0].ArticlePublishedTime
-			if pt != "" {
-				t, err := time.Parse(time.RFC3339, pt)
-				if err == nil {
-					publishedAt = t
-				} else {
-					logger.Warnw("Failed to parse Google CSE article:published_time", "value", pt, "error", err)
+		for _, mt := range item.Pagemap.Metatags {
+			for _, pt := range []string{mt.ArticlePublishedTime, mt.OGPublishedTime, mt.DatePublished, mt.PubDate} {
+				if pt != "" && publishedAt.IsZero() {
+					publishedAt = parsePublishTime("GoogleCSE", pt)
 				}
 			}
 		}
+		if publishedAt.IsZero() && item.FormattedTime != "" {
+			publishedAt = parsePublishTime("GoogleCSE", item.FormattedTime)
+		}
 
 		// fallback to zero time if no publishedAt found
 		articles = append(articles, NewsArticle{
@@ -402,10 +486,17 @@ func fetchFromGoogleCSE(ctx context.Context, company string, cfg *NewsPipelineCo
 	return articles, nil
 }
 
-// doGetWithRetry is a helper that does a GET request with a retry on failure
-func doGetWithRetry(ctx context.Context, url string) ([]byte, error) {
+// retryDelay is the pause between attempts of doGetWithRetry.
+var retryDelay = 500 * time.Millisecond
+
+// doGetWithRetry is a helper that does a GET request with a retry on failure.
+// Retries and the provider's remaining rate-limit quota are recorded in rep.
+func doGetWithRetry(ctx context.Context, url string, rep *SourceReport) ([]byte, error) {
 	var lastErr error
 	for i := 0; i < 3; i++ {
+		if i > 0 {
+			rep.Retries++
+		}
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
 			return nil, err
@@ -413,15 +504,16 @@ func doGetWithRetry(ctx context.Context, url string) ([]byte, error) {
 		resp, err := client.Do(req)
 		if err != nil {
 			lastErr = err
-			time.Sleep(500 * time.Millisecond)
+			time.Sleep(retryDelay)
 			continue
 		}
+		rep.recordQuota(resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
 			lastErr = fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
-			time.Sleep(500 * time.Millisecond)
+			time.Sleep(retryDelay)
 			continue
 		}
 
@@ -429,7 +521,7 @@ func doGetWithRetry(ctx context.Context, url string) ([]byte, error) {
 		resp.Body.Close()
 		if err != nil {
 			lastErr = err
-			time.Sleep(500 * time.Millisecond)
+			time.Sleep(retryDelay)
 			continue
 		}
 
@@ -444,8 +536,7 @@ func deduplicateArticles(articles []NewsArticle) []NewsArticle {
 	result := make([]NewsArticle, 0, len(articles))
 
 	for _, a := range articles {
-		// Create a unique key by URL + title hash
-		key := fmt.Sprintf("%s_%x", a.URL, sha1.Sum([]byte(a.Title)))
+		key := articleKey(a)
 		if _, found := seen[key]; !found {
 			seen[key] = struct{}{}
 			result = append(result, a)
@@ -454,3 +545,44 @@ func deduplicateArticles(articles []NewsArticle) []NewsArticle {
 
 	return result
 }
+
+// articleKey creates a unique key by URL + title hash
+func articleKey(a NewsArticle) string {
+	return fmt.Sprintf("%s_%x", a.URL, sha1.Sum([]byte(a.Title)))
+}
+
+// ArticleDeduper applies the same URL + title dedup as the batch pipeline to
+// long-running streams, remembering a bounded number of recent keys.
+type ArticleDeduper struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	keys []string
+	next int
+}
+
+// NewArticleDeduper creates a deduper that remembers up to size keys.
+func NewArticleDeduper(size int) *ArticleDeduper {
+	return &ArticleDeduper{
+		seen: make(map[string]struct{}, size),
+		keys: make([]string, size),
+	}
+}
+
+// Seen reports whether the article was already seen, recording it if not.
+func (d *ArticleDeduper) Seen(a NewsArticle) bool {
+	key := articleKey(a)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, found := d.seen[key]; found {
+		return true
+	}
+	if old := d.keys[d.next]; old != "" {
+		delete(d.seen, old)
+	}
+	d.keys[d.next] = key
+	d.next = (d.next + 1) % len(d.keys)
+	d.seen[key] = struct{}{}
+	return false
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// SourceReport holds fetch statistics for a single news source in one pipeline run.
+type SourceReport struct {
+	Source         string  `json:"source"`
+	Skipped        bool    `json:"skipped"`
+	Articles       int     `json:"articles"`
+	LatencySeconds float64 `json:"latency_seconds"`
+	Retries        int     `json:"retries"`
+	QuotaRemaining int     `json:"quota_remaining"` // -1 when the provider doesn't report it
+	Error          string  `json:"error,omitempty"`
+}
+
+// PipelineReport is the structured result of RunNewsPipelineReport.
+type PipelineReport struct {
+	Company         string         `json:"company"`
+	StartedAt       time.Time      `json:"started_at"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Sources         []SourceReport `json:"sources"`
+	Fetched         int            `json:"fetched"`    // articles returned by all sources
+	Duplicates      int            `json:"duplicates"` // dropped by dedup
+	Filtered        int            `json:"filtered"`   // dropped by age filter
+	Unique          int            `json:"unique"`     // articles returned to the caller
+
+	CorroborationChecked int `json:"corroboration_checked"`
+	Uncorroborated       int `json:"uncorroborated"` // checked but not found in trusted feeds
+
+	Articles []NewsArticle `json:"-"`
+}
+
+// recordQuota reads the remaining request quota from common rate-limit headers.
+func (r *SourceReport) recordQuota(h http.Header) {
+	for _, key := range []string{"X-RateLimit-Remaining", "X-Ratelimit-Remaining-Day", "RateLimit-Remaining"} {
+		if v := h.Get(key); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				r.QuotaRemaining = n
+				return
+			}
+		}
+	}
+}
+
+// JSON returns the report serialized for monitoring.
+func (r *PipelineReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// WriteText prints the report as a human readable table.
+func (r *PipelineReport) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "News pipeline for %s (%.2fs)\n", r.Company, r.DurationSeconds)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tARTICLES\tLATENCY\tRETRIES\tQUOTA\tSTATUS")
+	for _, s := range r.Sources {
+		status := "ok"
+		switch {
+		case s.Skipped:
+			status = "skipped"
+		case s.Error != "":
+			status = "error: " + s.Error
+		}
+		quota := "-"
+		if s.QuotaRemaining >= 0 {
+			quota = strconv.Itoa(s.QuotaRemaining)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.2fs\t%d\t%s\t%s\n", s.Source, s.Articles, s.LatencySeconds, s.Retries, quota, status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Fetched %d | duplicates %d | filtered %d | unique %d | uncorroborated %d/%d\n",
+		r.Fetched, r.Duplicates, r.Filtered, r.Unique, r.Uncorroborated, r.CorroborationChecked)
+	return err
+}
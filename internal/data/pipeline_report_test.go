@@ -0,0 +1,227 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubSources answers the pipeline's requests by host in place of the news
+// providers; a host without a handler fails like an unreachable provider.
+type stubSources map[string]http.HandlerFunc
+
+func (s stubSources) RoundTrip(req *http.Request) (*http.Response, error) {
+	h, found := s[req.URL.Host]
+	if !found {
+		return nil, fmt.Errorf("dial %s: connection refused", req.URL.Host)
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec.Result(), nil
+}
+
+func respond(status int, header http.Header, body any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		if s, ok := body.(string); ok {
+			fmt.Fprint(w, s)
+			return
+		}
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// useStubSources routes the pipeline's HTTP client to sources for the test.
+func useStubSources(t *testing.T, sources stubSources) {
+	for _, key := range []string{"MARKETAUX_API_KEY", "FINNHUB_API_KEY", "EODHD_API_KEY", "GOOGLE_CSE_API_KEY", "GOOGLE_CSE_ID"} {
+		t.Setenv(key, "test")
+	}
+	oldClient, oldDelay := client, retryDelay
+	client, retryDelay = &http.Client{Transport: sources}, 0
+	t.Cleanup(func() { client, retryDelay = oldClient, oldDelay })
+}
+
+func TestRunNewsPipelineReport(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	marketaux := respond(http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"97"}}, map[string]any{"data": []map[string]string{
+		{"title": "Infosys wins deal", "url": "https://a.example/1", "source": "a.example", "published_at": now.Add(-time.Hour).Format(time.RFC3339)},
+		{"title": "Infosys raises guidance", "url": "https://a.example/2", "source": "a.example", "published_at": now.Add(-2 * time.Hour).Format(time.RFC3339)},
+		{"title": "Infosys Q1 preview", "url": "https://a.example/3", "source": "a.example", "published_at": now.AddDate(0, 0, -30).Format(time.RFC3339)},
+	}})
+	finnhub := respond(http.StatusOK, http.Header{"Ratelimit-Remaining": {"59"}}, []map[string]any{
+		{"headline": "Infosys wins deal", "url": "https://a.example/1", "source": "a.example", "datetime": now.Add(-time.Hour).Unix()},
+		{"headline": "Infosys shares rise", "url": "https://b.example/1", "source": "b.example", "datetime": now.Unix()},
+	})
+	overQuota := respond(http.StatusTooManyRequests, http.Header{"X-Ratelimit-Remaining": {"0"}}, "daily limit exceeded")
+	failing := respond(http.StatusInternalServerError, nil, "internal error")
+
+	tests := []struct {
+		name    string
+		sources stubSources
+		cfg     NewsPipelineConfig
+		want    []SourceReport // latency is not compared
+		fetched int
+		dups    int
+		dropped int
+		unique  int
+		errs    []string
+	}{
+		{
+			name: "one failing and one over quota",
+			sources: stubSources{
+				"api.marketaux.com":     marketaux,
+				"finnhub.io":            finnhub,
+				"eodhistoricaldata.com": failing,
+				"www.googleapis.com":    overQuota,
+			},
+			cfg: NewsPipelineConfig{UseMarketaux: true, MarketauxLimit: 10, UseFinnhub: true, FinnhubLimit: 10,
+				UseEODHD: true, EODHDLimit: 10, UseGoogleCSE: true, GoogleCSELimit: 10, MaxAge: 7 * 24 * time.Hour},
+			want: []SourceReport{
+				{Source: "Marketaux", Articles: 3, QuotaRemaining: 97},
+				{Source: "Finnhub", Articles: 2, QuotaRemaining: 59},
+				{Source: "EODHD", Retries: 2, QuotaRemaining: -1, Error: "status 500: internal error"},
+				{Source: "GoogleCSE", Retries: 2, QuotaRemaining: 0, Error: "status 429: daily limit exceeded"},
+			},
+			fetched: 5, dups: 1, dropped: 1, unique: 3,
+			errs: []string{"EODHD: status 500: internal error", "GoogleCSE: status 429: daily limit exceeded"},
+		},
+		{
+			name: "unreachable source and skipped sources",
+			sources: stubSources{
+				"finnhub.io": finnhub,
+			},
+			cfg: NewsPipelineConfig{UseMarketaux: true, MarketauxLimit: 10, UseFinnhub: true, FinnhubLimit: 10,
+				UseEODHD: true, EODHDLimit: 0, UseGoogleCSE: true, GoogleCSELimit: 10, SkipGoogleCSE: true},
+			want: []SourceReport{
+				{Source: "Marketaux", Retries: 2, QuotaRemaining: -1, Error: `Get "https://api.marketaux.com/v1/news/all?filter_entities=true&entities=INFY&api_token=test": dial api.marketaux.com: connection refused`},
+				{Source: "Finnhub", Articles: 2, QuotaRemaining: 59},
+				{Source: "EODHD", Skipped: true, QuotaRemaining: -1},
+				{Source: "GoogleCSE", Skipped: true, QuotaRemaining: -1},
+			},
+			fetched: 2, dups: 0, dropped: 0, unique: 2,
+			errs: []string{"Marketaux: Get"},
+		},
+		{
+			name: "without max age nothing is filtered",
+			sources: stubSources{
+				"api.marketaux.com": marketaux,
+				"finnhub.io":        finnhub,
+			},
+			cfg: NewsPipelineConfig{UseMarketaux: true, MarketauxLimit: 10, UseFinnhub: true, FinnhubLimit: 10},
+			want: []SourceReport{
+				{Source: "Marketaux", Articles: 3, QuotaRemaining: 97},
+				{Source: "Finnhub", Articles: 2, QuotaRemaining: 59},
+				{Source: "EODHD", Skipped: true, QuotaRemaining: -1},
+				{Source: "GoogleCSE", Skipped: true, QuotaRemaining: -1},
+			},
+			fetched: 5, dups: 1, dropped: 0, unique: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useStubSources(t, tt.sources)
+			report, err := RunNewsPipelineReport(context.Background(), "INFY", &tt.cfg)
+
+			for _, want := range tt.errs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %v, want it to contain %q", err, want)
+				}
+			}
+			if len(tt.errs) == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if len(report.Sources) != len(tt.want) {
+				t.Fatalf("%d source reports, want %d", len(report.Sources), len(tt.want))
+			}
+			for i, got := range report.Sources {
+				got.LatencySeconds = 0
+				if got != tt.want[i] {
+					t.Errorf("source %d = %+v\nwant %+v", i, got, tt.want[i])
+				}
+			}
+			if report.Fetched != tt.fetched || report.Duplicates != tt.dups || report.Filtered != tt.dropped || report.Unique != tt.unique {
+				t.Errorf("fetched %d, duplicates %d, filtered %d, unique %d; want %d, %d, %d, %d",
+					report.Fetched, report.Duplicates, report.Filtered, report.Unique, tt.fetched, tt.dups, tt.dropped, tt.unique)
+			}
+			if len(report.Articles) != report.Unique {
+				t.Errorf("%d articles for %d unique", len(report.Articles), report.Unique)
+			}
+			for _, a := range report.Articles {
+				if a.PublishedAt.Location() != time.UTC {
+					t.Errorf("%q published at %v, want UTC", a.Title, a.PublishedAt)
+				}
+			}
+
+			var text bytes.Buffer
+			if err := report.WriteText(&text); err != nil {
+				t.Fatal(err)
+			}
+			summary := fmt.Sprintf("Fetched %d | duplicates %d | filtered %d | unique %d", tt.fetched, tt.dups, tt.dropped, tt.unique)
+			if !strings.Contains(text.String(), summary) {
+				t.Errorf("report text lacks %q:\n%s", summary, text.String())
+			}
+		})
+	}
+}
+
+func TestRecordQuota(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"not reported", http.Header{}, -1},
+		{"x-ratelimit", http.Header{"X-Ratelimit-Remaining": {"42"}}, 42},
+		{"daily", http.Header{"X-Ratelimit-Remaining-Day": {"7"}}, 7},
+		{"ietf draft", http.Header{"Ratelimit-Remaining": {"0"}}, 0},
+		{"first header wins", http.Header{"X-Ratelimit-Remaining": {"3"}, "Ratelimit-Remaining": {"9"}}, 3},
+		{"unparsable value is skipped", http.Header{"X-Ratelimit-Remaining": {"many"}, "Ratelimit-Remaining": {"9"}}, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := SourceReport{QuotaRemaining: -1}
+			r.recordQuota(tt.header)
+			if r.QuotaRemaining != tt.want {
+				t.Errorf("quota = %d, want %d", r.QuotaRemaining, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineReportWriteText(t *testing.T) {
+	report := PipelineReport{
+		Company:         "INFY",
+		DurationSeconds: 1.5,
+		Sources: []SourceReport{
+			{Source: "Marketaux", Articles: 3, LatencySeconds: 0.25, QuotaRemaining: 97},
+			{Source: "EODHD", LatencySeconds: 1.5, Retries: 2, QuotaRemaining: -1, Error: "status 500: internal error"},
+			{Source: "GoogleCSE", Skipped: true, QuotaRemaining: -1},
+		},
+		Fetched: 5, Duplicates: 1, Filtered: 1, Unique: 3,
+		CorroborationChecked: 2, Uncorroborated: 1,
+	}
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `News pipeline for INFY (1.50s)
+SOURCE     ARTICLES  LATENCY  RETRIES  QUOTA  STATUS
+Marketaux  3         0.25s    0        97     ok
+EODHD      0         1.50s    2        -      error: status 500: internal error
+GoogleCSE  0         0.00s    0        -      skipped
+Fetched 5 | duplicates 1 | filtered 1 | unique 3 | uncorroborated 1/2
+`
+	if buf.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -12,3 +12,19 @@ func CleanText(raw string) string {
 	clean = strings.TrimSpace(clean)
 	return clean
 }
+
+var (
+	urlRe            = regexp.MustCompile(`https?://\S+`)
+	sentimentPunctRe = regexp.MustCompile(`[^\w\s'-]`)
+)
+
+// CleanSentimentText is CleanText for the sentiment models. It keeps
+// apostrophes, so that the lexicon fallback sees negations such as "didn't",
+// and hyphens, which join words such as "write-off".
+func CleanSentimentText(raw string) string {
+	clean := strings.ToLower(raw)
+	clean = strings.ReplaceAll(clean, "’", "'")
+	clean = urlRe.ReplaceAllString(clean, "")
+	clean = sentimentPunctRe.ReplaceAllString(clean, "")
+	return strings.Join(strings.Fields(clean), " ")
+}
@@ -0,0 +1,21 @@
+package data
+
+import "testing"
+
+func TestCleanSentimentText(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"Infosys didn't beat estimates", "infosys didn't beat estimates"},
+		{"TCS won’t cut guidance", "tcs won't cut guidance"},
+		{"Bank takes a write-off, shares fall!", "bank takes a write-off shares fall"},
+		{"Read more: https://example.com/a?b=1  (Reuters)", "read more reuters"},
+		{"  \t ", ""},
+	}
+	for _, tt := range tests {
+		if got := CleanSentimentText(tt.raw); got != tt.want {
+			t.Errorf("CleanSentimentText(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
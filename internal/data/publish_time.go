@@ -0,0 +1,339 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IST is Indian Standard Time. A fixed zone is used so that the bot doesn't
+// depend on tzdata being installed on the host.
+var IST = time.FixedZone("IST", 5*60*60+30*60)
+
+// publishTimeLayouts lists the layouts seen from our providers, most common first.
+// Layouts without a zone are interpreted as UTC.
+var publishTimeLayouts = []string{
+	time.RFC3339Nano,                     // Marketaux, EODHD, most metatags
+	"2006-01-02T15:04:05.999999999Z0700", // +0530 without colon
+	"2006-01-02T15:04:05.999999999",      // Marketaux/EODHD variants without zone
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC850,
+	time.ANSIC,
+	"2 Jan 2006 15:04:05",
+	"Jan 2, 2006 15:04 MST",
+	"Jan 2, 2006 15:04 -0700",
+	"Jan 2, 2006, 3:04 PM MST",
+	"Jan 2, 2006, 3:04 PM -0700",
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"02-01-2006",
+	"2006/01/02",
+	"2006-01-02",
+	"20060102T150405Z0700",
+	"20060102",
+}
+
+// zoneAbbreviations are the zones our providers name by abbreviation.
+// Abbreviations shared by several zones, such as CST or BST, are left out.
+var zoneAbbreviations = map[string]*time.Location{
+	"UTC":  time.UTC,
+	"GMT":  time.UTC,
+	"IST":  IST,
+	"SGT":  time.FixedZone("SGT", 8*60*60),
+	"HKT":  time.FixedZone("HKT", 8*60*60),
+	"JST":  time.FixedZone("JST", 9*60*60),
+	"CET":  time.FixedZone("CET", 1*60*60),
+	"CEST": time.FixedZone("CEST", 2*60*60),
+	"EST":  time.FixedZone("EST", -5*60*60),
+	"EDT":  time.FixedZone("EDT", -4*60*60),
+	"PST":  time.FixedZone("PST", -8*60*60),
+	"PDT":  time.FixedZone("PDT", -7*60*60),
+}
+
+// zoneAbbreviationRe splits a trailing zone abbreviation from the time.
+var zoneAbbreviationRe = regexp.MustCompile(`^(.*\S)\s+([A-Z]{3,5})$`)
+
+var relativeTimeRe = regexp.MustCompile(`^(an?|\d+)\s*(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?|w|wks?|weeks?|months?)\s+ago$`)
+
+// ParsePublishTime parses a provider's publish time into UTC. Besides
+// absolute timestamps it accepts unix seconds/milliseconds and relative
+// strings such as "3 hours ago" or "yesterday", which are resolved against now.
+func ParsePublishTime(value string, now time.Time) (time.Time, error) {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return time.Time{}, errors.New("empty publish time")
+	}
+
+	if t, ok := parseRelativeTime(strings.ToLower(v), now); ok {
+		return t.UTC(), nil
+	}
+
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil && len(v) >= 9 {
+		if len(v) >= 13 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+
+	// time.Parse reads any zone abbreviation other than UTC, GMT and the
+	// host's own as UTC. Parse the listed ones in their zone, which gives
+	// the abbreviation its offset in the layouts with a zone, and reject
+	// the rest rather than shift them silently.
+	if m := zoneAbbreviationRe.FindStringSubmatch(v); m != nil {
+		loc, found := zoneAbbreviations[m[2]]
+		if !found {
+			return time.Time{}, fmt.Errorf("unknown time zone %q in publish time %q", m[2], value)
+		}
+		for _, layout := range publishTimeLayouts {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t.UTC(), nil
+			}
+			if t, err := time.ParseInLocation(layout, m[1], loc); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized publish time format: %q", value)
+	}
+
+	for _, layout := range publishTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized publish time format: %q", value)
+}
+
+// unixTime converts a provider's unix timestamp, where 0 means the time is
+// not known, to UTC.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+func parseRelativeTime(v string, now time.Time) (time.Time, bool) {
+	switch v {
+	case "just now", "now":
+		return now, true
+	case "today":
+		return now, true
+	case "yesterday":
+		return now.AddDate(0, 0, -1), true
+	}
+
+	m := relativeTimeRe.FindStringSubmatch(v)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n := 1
+	if m[1] != "a" && m[1] != "an" {
+		n, _ = strconv.Atoi(m[1])
+	}
+
+	switch unit := m[2]; {
+	case strings.HasPrefix(unit, "mo"):
+		return now.AddDate(0, -n, 0), true
+	case strings.HasPrefix(unit, "s"):
+		return now.Add(-time.Duration(n) * time.Second), true
+	case strings.HasPrefix(unit, "m"):
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case strings.HasPrefix(unit, "h"):
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case strings.HasPrefix(unit, "d"):
+		return now.AddDate(0, 0, -n), true
+	case strings.HasPrefix(unit, "w"):
+		return now.AddDate(0, 0, -7*n), true
+	}
+	return time.Time{}, false
+}
+
+// parsePublishTime is the fetchers' wrapper around ParsePublishTime that logs
+// failures and falls back to the zero time.
+func parsePublishTime(source, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := ParsePublishTime(value, time.Now())
+	if err != nil {
+		logger.Warnw("Failed to parse published_at", "source", source, "value", value, "error", err)
+		return time.Time{}
+	}
+	return t
+}
+
+// MarketSession tags when, relative to NSE trading hours, an article was published.
+type MarketSession string
+
+const (
+	SessionPreMarket  MarketSession = "pre-market"
+	SessionMarket     MarketSession = "market"
+	SessionPostMarket MarketSession = "post-market"
+	SessionClosed     MarketSession = "closed"
+	SessionHoliday    MarketSession = "holiday"
+	SessionUnknown    MarketSession = "unknown"
+)
+
+// MarketCalendar classifies timestamps into NSE sessions. Times are minutes
+// after midnight IST.
+type MarketCalendar struct {
+	PreOpen   int
+	Open      int
+	Close     int
+	PostClose int
+	Holidays  map[string]struct{} // dates as 2006-01-02 in IST
+
+	years  map[int]struct{} // years with at least one holiday listed
+	warned sync.Map         // years already reported as missing
+}
+
+// DefaultHolidaysPath is the NSE holiday list loaded at startup.
+const DefaultHolidaysPath = "configs/nse_holidays.yaml"
+
+// NSECalendar is the calendar used to tag articles. It is loaded from
+// DefaultHolidaysPath when that file exists; the holidays below are the
+// fallback for running outside the repository.
+var NSECalendar = NewMarketCalendar(
+	"2025-02-26", "2025-03-14", "2025-03-31", "2025-04-10", "2025-04-14",
+	"2025-04-18", "2025-05-01", "2025-08-15", "2025-08-27", "2025-10-02",
+	"2025-10-21", "2025-10-22", "2025-11-05", "2025-12-25",
+	"2026-01-15", "2026-01-26", "2026-03-03", "2026-03-26", "2026-03-31",
+	"2026-04-03", "2026-04-14", "2026-05-01", "2026-05-28", "2026-06-26",
+	"2026-09-14", "2026-10-02", "2026-10-20", "2026-11-10", "2026-11-24",
+	"2026-12-25",
+)
+
+func init() {
+	c, err := LoadMarketCalendar(DefaultHolidaysPath)
+	switch {
+	case err == nil:
+		NSECalendar = c
+	case !errors.Is(err, os.ErrNotExist):
+		logger.Warnw("Using built-in NSE holidays", "error", err)
+	}
+	if year := time.Now().In(IST).Year(); !NSECalendar.HasYear(year) {
+		logger.Warnw("No NSE holidays listed for the current year, only weekends count as holidays",
+			"year", year, "file", DefaultHolidaysPath)
+	}
+}
+
+// LoadMarketCalendar reads holidays from a YAML file that maps each year to
+// its list of dates (2006-01-02, IST).
+func LoadMarketCalendar(path string) (*MarketCalendar, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	var byYear map[int][]string
+	if err := yaml.Unmarshal(raw, &byYear); err != nil {
+		return nil, fmt.Errorf("failed to parse holidays %s: %w", path, err)
+	}
+
+	var holidays []string
+	for year, dates := range byYear {
+		for _, d := range dates {
+			t, err := time.Parse("2006-01-02", d)
+			if err != nil {
+				return nil, fmt.Errorf("holidays %s: invalid date %q", path, d)
+			}
+			if t.Year() != year {
+				return nil, fmt.Errorf("holidays %s: %s is listed under %d", path, d, year)
+			}
+			holidays = append(holidays, d)
+		}
+	}
+	sort.Strings(holidays)
+	return NewMarketCalendar(holidays...), nil
+}
+
+// NewMarketCalendar creates a calendar with regular NSE equity hours:
+// pre-open 09:00, open 09:15, close 15:30 and post-close session until 16:00.
+func NewMarketCalendar(holidays ...string) *MarketCalendar {
+	c := &MarketCalendar{
+		PreOpen:   9 * 60,
+		Open:      9*60 + 15,
+		Close:     15*60 + 30,
+		PostClose: 16 * 60,
+		Holidays:  make(map[string]struct{}, len(holidays)),
+		years:     make(map[int]struct{}),
+	}
+	for _, d := range holidays {
+		c.Holidays[d] = struct{}{}
+		if t, err := time.Parse("2006-01-02", d); err == nil {
+			c.years[t.Year()] = struct{}{}
+		}
+	}
+	return c
+}
+
+// HasYear reports whether the calendar lists holidays for year.
+func (c *MarketCalendar) HasYear(year int) bool {
+	_, found := c.years[year]
+	return found
+}
+
+// Session returns the market session t falls in.
+func (c *MarketCalendar) Session(t time.Time) MarketSession {
+	if t.IsZero() {
+		return SessionUnknown
+	}
+	ist := t.In(IST)
+	if wd := ist.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return SessionHoliday
+	}
+	if _, found := c.Holidays[ist.Format("2006-01-02")]; found {
+		return SessionHoliday
+	}
+	if year := ist.Year(); !c.HasYear(year) {
+		if _, warned := c.warned.LoadOrStore(year, true); !warned {
+			logger.Warnw("No NSE holidays listed for year, exchange holidays are tagged as trading days", "year", year)
+		}
+	}
+
+	minute := ist.Hour()*60 + ist.Minute()
+	switch {
+	case minute >= c.PreOpen && minute < c.Open:
+		return SessionPreMarket
+	case minute >= c.Open && minute < c.Close:
+		return SessionMarket
+	case minute >= c.Close && minute < c.PostClose:
+		return SessionPostMarket
+	default:
+		return SessionClosed
+	}
+}
+
+// PublishedIST returns the publish time in Indian Standard Time.
+func (a NewsArticle) PublishedIST() time.Time {
+	if a.PublishedAt.IsZero() {
+		return a.PublishedAt
+	}
+	return a.PublishedAt.In(IST)
+}
+
+// normalizeArticle converts the publish time to UTC and tags its market session.
+func normalizeArticle(a *NewsArticle) {
+	if !a.PublishedAt.IsZero() {
+		a.PublishedAt = a.PublishedAt.UTC()
+	}
+	a.Session = NSECalendar.Session(a.PublishedAt)
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePublishTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 10, 18, 5, 0, 0, 0, time.UTC) // 10:30 IST
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-10-18T05:00:00Z", want},
+		{"2026-10-18T10:30:00+05:30", want},
+		{"2026-10-18T10:30:00+0530", want},
+		{"2026-10-18T05:00:00.000000", want},
+		{"Sun, 18 Oct 2026 05:00:00 GMT", want},
+		{"Sun, 18 Oct 2026 10:30:00 +0530", want},
+		{"1792299600", want},
+		{"1792299600000", want},
+		{"an hour ago", want},
+		{"60 mins ago", want},
+
+		// Indian providers.
+		{"Oct 18, 2026 10:30 IST", want},
+		{"Oct 18, 2026, 10:30 AM IST", want},
+		{"Sun, 18 Oct 2026 10:30:00 IST", want},
+		{"October 18, 2026 10:30 AM IST", want},
+		{"2026-10-18 10:30 IST", want},
+		{"18 Oct 2026 10:30:00 IST", want},
+
+		// Other listed abbreviations take their own offset, not UTC's.
+		{"Oct 18, 2026 01:00 EDT", want},
+		{"Oct 17, 2026, 10:00 PM PDT", want},
+		{"Sun, 18 Oct 2026 00:00:00 EST", want},
+		{"Sun, 18 Oct 2026 05:00:00 UTC", want},
+		{"2026-10-18 13:00 SGT", want},
+	}
+	for _, tt := range tests {
+		got, err := ParsePublishTime(tt.value, now)
+		if err != nil {
+			t.Errorf("ParsePublishTime(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParsePublishTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// Unknown or ambiguous abbreviations are rejected instead of read as UTC.
+	for _, bad := range []string{"", "soon", "18/10/2026 10:30 XYZ", "Oct 18, 2026 10:30 CST", "Sun, 18 Oct 2026 06:00:00 BST", "Oct 18, 2026 10:30 ABCDEF"} {
+		if _, err := ParsePublishTime(bad, now); err == nil {
+			t.Errorf("ParsePublishTime(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestMarketCalendarSession(t *testing.T) {
+	ist := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", s, IST)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	c := NewMarketCalendar("2026-10-20")
+
+	tests := []struct {
+		at   time.Time
+		want MarketSession
+	}{
+		{time.Time{}, SessionUnknown},
+		{ist("2026-10-19 09:05"), SessionPreMarket},
+		{ist("2026-10-19 09:15"), SessionMarket},
+		{ist("2026-10-19 15:29"), SessionMarket},
+		{ist("2026-10-19 15:45"), SessionPostMarket},
+		{ist("2026-10-19 20:00"), SessionClosed},
+		{ist("2026-10-20 11:00"), SessionHoliday}, // Dussehra
+		{ist("2026-10-18 11:00"), SessionHoliday}, // Sunday
+		{ist("2026-10-19 04:00").UTC(), SessionClosed},
+	}
+	for _, tt := range tests {
+		if got := c.Session(tt.at); got != tt.want {
+			t.Errorf("Session(%v) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMarketCalendar(t *testing.T) {
+	c, err := LoadMarketCalendar(filepath.Join("..", "..", DefaultHolidaysPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, year := range []int{2025, 2026} {
+		if !c.HasYear(year) {
+			t.Errorf("holidays file has no %d holidays", year)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "holidays.yaml")
+	os.WriteFile(path, []byte("2026:\n  - \"2025-12-25\"\n"), 0o644)
+	if _, err := LoadMarketCalendar(path); err == nil {
+		t.Error("date listed under the wrong year was accepted")
+	}
+}
@@ -0,0 +1,281 @@
+package data
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storyStopwords are dropped before comparing headlines so that shared filler
+// words don't make unrelated stories look alike.
+var storyStopwords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "as": {}, "at": {}, "by": {}, "for": {}, "from": {},
+	"in": {}, "is": {}, "it": {}, "its": {}, "of": {}, "on": {}, "or": {}, "says": {},
+	"the": {}, "to": {}, "with": {}, "after": {}, "over": {}, "amid": {}, "be": {},
+	"has": {}, "have": {}, "will": {}, "this": {}, "that": {}, "are": {}, "was": {},
+}
+
+// Story groups articles from different outlets covering the same event.
+// Articles without a publish time are placed at the time they were seen.
+type Story struct {
+	ID             string        `json:"id"`
+	Headline       string        `json:"headline"`
+	FirstPublished time.Time     `json:"first_published"` // earliest article
+	LastPublished  time.Time     `json:"last_published"`  // latest article
+	FirstSeen      time.Time     `json:"first_seen"`      // when the clusterer got the first article
+	LastSeen       time.Time     `json:"last_seen"`       // when the clusterer got the latest article
+	Articles       []NewsArticle `json:"articles"`
+	Publishers     []string      `json:"publishers"`
+
+	tokens []map[string]struct{}
+	times  []time.Time // publish or seen time of each article
+}
+
+// PublisherCount returns the number of distinct outlets covering the story.
+func (s *Story) PublisherCount() int {
+	return len(s.Publishers)
+}
+
+// CoverageRate returns articles per hour between the first article and now.
+func (s *Story) CoverageRate(now time.Time) float64 {
+	hours := now.Sub(s.FirstPublished).Hours()
+	if hours < 1.0/60 {
+		hours = 1.0 / 60 // avoid exploding rates in the first minute
+	}
+	return float64(len(s.Articles)) / hours
+}
+
+// Velocity returns articles per hour published within window before now,
+// which rises while a story is still breaking and decays once it goes quiet.
+func (s *Story) Velocity(now time.Time, window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+	count := 0
+	for _, t := range s.times {
+		if !t.After(now) && now.Sub(t) <= window {
+			count++
+		}
+	}
+	return float64(count) / window.Hours()
+}
+
+// StoryClusterer incrementally groups articles into stories by headline
+// similarity and time proximity.
+type StoryClusterer struct {
+	Threshold float64       // minimum Jaccard similarity of headline tokens
+	Window    time.Duration // maximum gap between an article and a story's latest coverage
+
+	mu      sync.Mutex
+	stories []*Story
+	byURL   map[string]*Story
+}
+
+// NewStoryClusterer creates a clusterer. Zero values default to a 0.5
+// similarity threshold and a 12 hour window.
+func NewStoryClusterer(threshold float64, window time.Duration) *StoryClusterer {
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+	if window <= 0 {
+		window = 12 * time.Hour
+	}
+	return &StoryClusterer{
+		Threshold: threshold,
+		Window:    window,
+		byURL:     make(map[string]*Story),
+	}
+}
+
+// ClusterStories groups a batch of articles into stories using a fresh clusterer.
+func ClusterStories(articles []NewsArticle, threshold float64, window time.Duration) []Story {
+	c := NewStoryClusterer(threshold, window)
+	c.AddAll(articles)
+	return c.Stories()
+}
+
+// AddAll adds articles in publish order so that story headlines are stable.
+func (c *StoryClusterer) AddAll(articles []NewsArticle) {
+	sorted := make([]NewsArticle, len(articles))
+	copy(sorted, articles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.Before(sorted[j].PublishedAt)
+	})
+	for _, a := range sorted {
+		c.Add(a)
+	}
+}
+
+// Add assigns an article to the most similar recent story, starting a new
+// story when none is close enough, and returns the story's ID.
+func (c *StoryClusterer) Add(a NewsArticle) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, found := c.byURL[a.URL]; found && a.URL != "" {
+		return s.ID
+	}
+	seen := time.Now()
+	at := a.PublishedAt
+	if at.IsZero() {
+		at = seen
+	}
+	tokens := headlineTokens(a.Title)
+
+	var best *Story
+	bestSim := 0.0
+	for _, s := range c.stories {
+		if at.Sub(s.LastPublished) > c.Window || s.FirstPublished.Sub(at) > c.Window {
+			continue
+		}
+		for _, t := range s.tokens {
+			if sim := jaccard(tokens, t); sim > bestSim {
+				bestSim = sim
+				best = s
+			}
+		}
+	}
+
+	if best == nil || bestSim < c.Threshold {
+		best = &Story{
+			ID:             fmt.Sprintf("%x", sha1.Sum([]byte(articleKey(a))))[:12],
+			Headline:       a.Title,
+			FirstPublished: at,
+			LastPublished:  at,
+			FirstSeen:      seen,
+		}
+		c.stories = append(c.stories, best)
+	}
+
+	best.Articles = append(best.Articles, a)
+	best.tokens = append(best.tokens, tokens)
+	best.times = append(best.times, at)
+	best.LastSeen = seen
+	if at.Before(best.FirstPublished) {
+		best.FirstPublished = at
+		best.Headline = a.Title
+	}
+	if at.After(best.LastPublished) {
+		best.LastPublished = at
+	}
+	if publisher := normalizePublisher(a.Source); publisher != "" && !containsString(best.Publishers, publisher) {
+		best.Publishers = append(best.Publishers, publisher)
+	}
+	if a.URL != "" {
+		c.byURL[a.URL] = best
+	}
+	return best.ID
+}
+
+// Stories returns a snapshot of all stories, most recently active first.
+func (c *StoryClusterer) Stories() []Story {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]Story, 0, len(c.stories))
+	for _, s := range c.stories {
+		out = append(out, s.snapshot())
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].LastPublished.After(out[j].LastPublished)
+	})
+	return out
+}
+
+// Story returns a snapshot of the story with the given ID.
+func (c *StoryClusterer) Story(id string) (Story, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range c.stories {
+		if s.ID == id {
+			return s.snapshot(), true
+		}
+	}
+	return Story{}, false
+}
+
+// Trending returns stories covered by at least minPublishers outlets, ordered
+// by their velocity over window.
+func (c *StoryClusterer) Trending(now time.Time, window time.Duration, minPublishers int) []Story {
+	var out []Story
+	for _, s := range c.Stories() {
+		if s.PublisherCount() >= minPublishers {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Velocity(now, window) > out[j].Velocity(now, window)
+	})
+	return out
+}
+
+// Prune forgets stories whose latest article was published before before.
+func (c *StoryClusterer) Prune(before time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.stories[:0]
+	for _, s := range c.stories {
+		if s.LastPublished.Before(before) {
+			for _, a := range s.Articles {
+				delete(c.byURL, a.URL)
+			}
+			continue
+		}
+		kept = append(kept, s)
+	}
+	c.stories = kept
+}
+
+func (s *Story) snapshot() Story {
+	cp := *s
+	cp.Articles = append([]NewsArticle(nil), s.Articles...)
+	cp.Publishers = append([]string(nil), s.Publishers...)
+	cp.times = append([]time.Time(nil), s.times...)
+	cp.tokens = nil
+	return cp
+}
+
+// headlineTokens returns the set of significant words in a headline.
+func headlineTokens(title string) map[string]struct{} {
+	tokens := make(map[string]struct{})
+	for _, w := range strings.Fields(CleanText(title)) {
+		if _, stop := storyStopwords[w]; stop || len(w) < 2 {
+			continue
+		}
+		tokens[w] = struct{}{}
+	}
+	return tokens
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|.
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range a {
+		if _, found := b[w]; found {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+func normalizePublisher(source string) string {
+	p := strings.ToLower(strings.TrimSpace(source))
+	return strings.TrimPrefix(p, "www.")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoryClustererGroupsCoverage(t *testing.T) {
+	t0 := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	c := NewStoryClusterer(0, 0)
+	a := c.Add(NewsArticle{Source: "Reuters", Title: "Infosys wins $2 billion deal from European bank", URL: "u1", PublishedAt: t0})
+	b := c.Add(NewsArticle{Source: "Mint", Title: "Infosys wins $2 billion European bank deal", URL: "u2", PublishedAt: t0.Add(time.Hour)})
+	other := c.Add(NewsArticle{Source: "Mint", Title: "RBI holds repo rate steady", URL: "u3", PublishedAt: t0.Add(time.Hour)})
+
+	if a != b {
+		t.Errorf("similar headlines got stories %s and %s", a, b)
+	}
+	if a == other {
+		t.Errorf("unrelated headline joined story %s", a)
+	}
+	if again := c.Add(NewsArticle{Title: "different title", URL: "u1"}); again != a {
+		t.Errorf("known URL got story %s, want %s", again, a)
+	}
+
+	s, _ := c.Story(a)
+	if s.PublisherCount() != 2 || !s.FirstPublished.Equal(t0) || !s.LastPublished.Equal(t0.Add(time.Hour)) {
+		t.Errorf("unexpected story %+v", s)
+	}
+	if v := s.Velocity(t0.Add(90*time.Minute), time.Hour); v != 1 {
+		t.Errorf("velocity = %v, want 1 article/hour", v)
+	}
+}
+
+func TestStoryClustererUnknownPublishTime(t *testing.T) {
+	c := NewStoryClusterer(0, 0)
+	before := time.Now()
+	id := c.Add(NewsArticle{Source: "Mint", Title: "Tata Motors recalls EVs", URL: "u1"})
+	after := time.Now()
+
+	s, _ := c.Story(id)
+	if !s.Articles[0].PublishedAt.IsZero() {
+		t.Errorf("stored article got publish time %v, want it left unknown", s.Articles[0].PublishedAt)
+	}
+	for name, ts := range map[string]time.Time{"FirstSeen": s.FirstSeen, "LastSeen": s.LastSeen, "FirstPublished": s.FirstPublished} {
+		if ts.Before(before) || ts.After(after) {
+			t.Errorf("%s = %v, want the time the article was added", name, ts)
+		}
+	}
+}
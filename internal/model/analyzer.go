@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrAnalyzerClosed is returned by an Analyzer after Close.
+var ErrAnalyzerClosed = errors.New("sentiment analyzer is closed")
+
+// ErrEmptyText is returned for texts that are empty or only whitespace,
+// which no model can score meaningfully.
+var ErrEmptyText = errors.New("empty text")
+
+// Analyzer loads a sentiment model once and scores texts using a pool of
+// ONNX sessions, each with its own preallocated tensors, so it is safe for
+// concurrent callers. Close releases all native resources.
+type Analyzer struct {
+	def       ModelDefinition
+	tokenizer *WordPieceTokenizer
+	runner    *onnxRunner
+}
+
+// BatchResult is the outcome for one text passed to AnalyzeBatch.
+type BatchResult struct {
+	SentimentResult
+	Err error
+}
+
+// NewAnalyzer initializes ONNX Runtime, checks the model file against its
+// definition, loads the tokenizer and creates the session pool.
+func NewAnalyzer(def ModelDefinition) (*Analyzer, error) {
+	def.applyDefaults()
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("model %q: %w", def.Name, err)
+	}
+	if def.Activation != ActivationSoftmax {
+		return nil, fmt.Errorf("model %q: sentiment models need %s activation, use a TextClassifier for %s", def.Name, ActivationSoftmax, def.Activation)
+	}
+	tok, err := loadONNXModel(def)
+	if err != nil {
+		return nil, err
+	}
+	runner, err := newONNXRunner(def, ErrAnalyzerClosed)
+	if err != nil {
+		return nil, err
+	}
+	return &Analyzer{def: def, tokenizer: tok, runner: runner}, nil
+}
+
+// Analyze returns the full prediction for text.
+func (a *Analyzer) Analyze(text string) (SentimentResult, error) {
+	if strings.TrimSpace(text) == "" {
+		return SentimentResult{}, ErrEmptyText
+	}
+	start := time.Now()
+	input := a.tokenizer.Encode(text)
+	logits, err := a.run([]TokenizedOutput{input})
+	if err != nil {
+		return SentimentResult{}, err
+	}
+	return newSentimentResult(a.def, softmax(logits[0]), input.Truncated, time.Since(start)), nil
+}
+
+// AnalyzeBatch scores texts in model runs of up to BatchSize texts and
+// returns one result per text, in order. A failing run only marks its own
+// texts as failed; the remaining batches still run. Each result's latency is
+// that of the run it was part of.
+func (a *Analyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	a.runner.runBatches(a.tokenizer, texts, func(idx []int, inputs []TokenizedOutput, logits [][]float32, latency time.Duration, err error) {
+		for j, i := range idx {
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].SentimentResult = newSentimentResult(a.def, softmax(logits[j]), inputs[j].Truncated, latency)
+		}
+	})
+	return results
+}
+
+// run executes one model run over inputs and returns a copy of each row of logits.
+func (a *Analyzer) run(inputs []TokenizedOutput) ([][]float32, error) {
+	return a.runner.run(inputs)
+}
+
+// Close waits for in-flight calls to finish and destroys all sessions and tensors.
+func (a *Analyzer) Close() error {
+	a.runner.close()
+	return nil
+}
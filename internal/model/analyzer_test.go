@@ -0,0 +1,86 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// stubAnalyzer returns an Analyzer over the test vocabulary whose model
+// runs are served by run instead of ONNX Runtime.
+func stubAnalyzer(t *testing.T, batchSize int, run func([]TokenizedOutput) ([][]float32, error)) *Analyzer {
+	t.Helper()
+	def := ModelDefinition{
+		Name:      "stub",
+		Type:      ModelTypeONNX,
+		Labels:    []string{"negative", "neutral", "positive"},
+		BatchSize: batchSize,
+	}
+	def.applyDefaults()
+	return &Analyzer{
+		def:       def,
+		tokenizer: loadTestTokenizer(t, def.MaxLength),
+		runner:    &onnxRunner{def: def, run: run},
+	}
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	errRun := errors.New("run failed")
+	ids := loadTestTokenizer(t, 8).vocab
+	// Each text's first word picks its class; a batch with "cafe" in it fails.
+	logitsFor := map[int64][]float32{
+		ids["bank"]:   {3, 0, 0},
+		ids["shares"]: {0, 3, 0},
+		ids["rose"]:   {0, 0, 3},
+	}
+	var batches [][]int64
+	run := func(inputs []TokenizedOutput) ([][]float32, error) {
+		var first []int64
+		for _, in := range inputs {
+			first = append(first, in.InputIDs[1])
+		}
+		batches = append(batches, first)
+		if slices.Contains(first, ids["cafe"]) {
+			return nil, errRun
+		}
+		logits := make([][]float32, len(inputs))
+		for i, id := range first {
+			logits[i] = logitsFor[id]
+		}
+		return logits, nil
+	}
+	a := stubAnalyzer(t, 2, run)
+
+	texts := []string{"rose", "bank", "  ", "shares", "cafe", "rose", "bank"}
+	results := a.AnalyzeBatch(texts)
+	if len(results) != len(texts) {
+		t.Fatalf("got %d results for %d texts", len(results), len(texts))
+	}
+	// Six non-empty texts run as three batches of two, in input order.
+	if len(batches) != 3 || len(batches[0]) != 2 || len(batches[1]) != 2 || len(batches[2]) != 2 {
+		t.Fatalf("model runs = %v, want three runs of two texts", batches)
+	}
+
+	want := []struct {
+		label string
+		err   error
+	}{
+		{"positive", nil},
+		{"negative", nil},
+		{"", ErrEmptyText},
+		{"", errRun}, // shares its batch with "cafe"
+		{"", errRun},
+		{"positive", nil}, // the batch after the failed one still runs
+		{"negative", nil},
+	}
+	for i, w := range want {
+		r := results[i]
+		if !errors.Is(r.Err, w.err) {
+			t.Errorf("text %d %q: err = %v, want %v", i, texts[i], r.Err, w.err)
+			continue
+		}
+		if r.Label != w.label {
+			t.Errorf("text %d %q: label = %q, want %q", i, texts[i], r.Label, w.label)
+		}
+	}
+}
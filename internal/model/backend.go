@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+)
+
+// Model types accepted in configs/model.yaml.
+const (
+	ModelTypeONNX     = "onnx"
+	ModelTypeLexicon  = "lexicon"
+	ModelTypeEnsemble = "ensemble"
+)
+
+// SentimentAnalyzer is implemented by every sentiment backend.
+type SentimentAnalyzer interface {
+	Analyze(text string) (SentimentResult, error)
+	AnalyzeLong(text string) (SentimentResult, error)
+	AnalyzeBatch(texts []string) []BatchResult
+	Close() error
+}
+
+var (
+	_ SentimentAnalyzer = (*Analyzer)(nil)
+	_ SentimentAnalyzer = (*LexiconAnalyzer)(nil)
+	_ SentimentAnalyzer = (*CachedAnalyzer)(nil)
+	_ SentimentAnalyzer = (*EnsembleAnalyzer)(nil)
+	_ SentimentAnalyzer = (*ShadowAnalyzer)(nil)
+	_ SentimentAnalyzer = (*SwappableAnalyzer)(nil)
+	_ SentimentAnalyzer = (*MonitoredAnalyzer)(nil)
+)
+
+// NewSentimentAnalyzer creates the backend for a model definition. Ensembles
+// need the definitions of their members and are created by
+// ModelConfig.NewAnalyzer instead.
+func NewSentimentAnalyzer(def ModelDefinition) (SentimentAnalyzer, error) {
+	switch def.Type {
+	case ModelTypeONNX, "":
+		a, err := NewAnalyzer(def)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case ModelTypeLexicon:
+		return NewLexiconAnalyzer(def), nil
+	case ModelTypeEnsemble:
+		return nil, fmt.Errorf("ensemble %q must be created with ModelConfig.NewAnalyzer", def.Name)
+	default:
+		return nil, fmt.Errorf("model %q has unknown type %q", def.Name, def.Type)
+	}
+}
+
+// NewAnalyzer creates the backend for def, including ensembles, without
+// fallback, shadows or caching.
+func (c *ModelConfig) NewAnalyzer(def ModelDefinition) (SentimentAnalyzer, error) {
+	if def.Type != ModelTypeEnsemble {
+		return NewSentimentAnalyzer(def)
+	}
+	e, err := newEnsembleAnalyzer(c, def)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// OpenSentimentAnalyzer creates the named model (the default one if name is
+// empty). If it can't be created, for example because ONNX Runtime isn't
+// installed, and the config names a fallback model, the fallback is returned.
+// Configured shadow models then run alongside it, and when the config
+// enables the cache, the returned analyzer is cached. Predictions are
+// recorded in Prometheus, checked for drift and routed to the labelling
+// queue when the config enables those.
+func OpenSentimentAnalyzer(cfg *ModelConfig, name string) (SentimentAnalyzer, error) {
+	analyzer, _, err := openSentimentAnalyzer(cfg, name)
+	return analyzer, err
+}
+
+// openSentimentAnalyzer is OpenSentimentAnalyzer that also returns the
+// definition of the model that was opened.
+func openSentimentAnalyzer(cfg *ModelConfig, name string) (SentimentAnalyzer, ModelDefinition, error) {
+	analyzer, def, err := openWithFallback(cfg, name)
+	if err != nil {
+		return nil, def, err
+	}
+	var labels *LabelRouter
+	if cfg.Labeling.Enabled() {
+		if labels, err = newLabelRouter(cfg.Labeling); err != nil {
+			analyzer.Close()
+			return nil, def, err
+		}
+	}
+	if len(cfg.Shadow.Models) > 0 {
+		shadowed, err := newShadowAnalyzer(cfg, analyzer, labels)
+		if err != nil {
+			analyzer.Close()
+			return nil, def, err
+		}
+		analyzer = shadowed
+	}
+	if cfg.Cache.Enabled() {
+		cached, err := NewCachedAnalyzer(analyzer, def, cfg.Cache)
+		if err != nil {
+			analyzer.Close()
+			return nil, def, err
+		}
+		analyzer = cached
+	}
+	var drift *DriftDetector
+	if cfg.Drift.Enabled() {
+		if drift, err = openDriftDetector(def, cfg.Drift); err != nil {
+			analyzer.Close()
+			return nil, def, err
+		}
+	}
+	return NewMonitoredAnalyzer(analyzer, def, drift, labels), def, nil
+}
+
+// openWithFallback returns the analyzer together with the definition of the
+// model that was actually opened.
+func openWithFallback(cfg *ModelConfig, name string) (SentimentAnalyzer, ModelDefinition, error) {
+	def, err := cfg.Model(name)
+	if err != nil {
+		return nil, def, err
+	}
+	analyzer, err := cfg.NewAnalyzer(def)
+	if err == nil {
+		return analyzer, def, nil
+	}
+	if cfg.Fallback == "" || cfg.Fallback == def.Name {
+		return nil, def, err
+	}
+
+	fallback, ferr := cfg.Model(cfg.Fallback)
+	if ferr != nil {
+		return nil, def, fmt.Errorf("%w (fallback: %v)", err, ferr)
+	}
+	fmt.Printf("Sentiment model %q unavailable (%v), falling back to %q\n", def.Name, err, fallback.Name)
+	analyzer, err = cfg.NewAnalyzer(fallback)
+	return analyzer, fallback, err
+}
@@ -0,0 +1,200 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrBatcherClosed is returned for requests submitted after Close.
+var ErrBatcherClosed = errors.New("micro-batcher is closed")
+
+var (
+	batchSizeHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_batch_size",
+			Help:    "Number of texts per micro-batched model run",
+			Buckets: prometheus.LinearBuckets(1, 4, 8), // 1 to 29
+		},
+	)
+	batchQueueWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_batch_queue_wait_seconds",
+			Help:    "Time a text waited in the micro-batch queue before its model run",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 10), // 1ms to ~0.5s
+		},
+	)
+	batchQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sentiment_batch_queue_depth",
+			Help: "Texts waiting in the micro-batch queue",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(batchSizeHistogram, batchQueueWait, batchQueueDepth)
+}
+
+// MicroBatcher merges concurrent single-text requests into batched model
+// runs. A batch is run once it holds MaxBatch texts or its first text has
+// waited MaxDelay, whichever comes first.
+type MicroBatcher struct {
+	analyzer SentimentAnalyzer
+	maxBatch int
+	maxDelay time.Duration
+
+	queue  chan *batchRequest
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type batchRequest struct {
+	text     string
+	enqueued time.Time
+	done     chan BatchResult
+}
+
+// NewMicroBatcher starts workers goroutines that each collect and run batches.
+func NewMicroBatcher(analyzer SentimentAnalyzer, maxBatch int, maxDelay time.Duration, workers int) *MicroBatcher {
+	if maxBatch <= 0 {
+		maxBatch = 16
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Millisecond
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	b := &MicroBatcher{
+		analyzer: analyzer,
+		maxBatch: maxBatch,
+		maxDelay: maxDelay,
+		queue:    make(chan *batchRequest, maxBatch*workers*4),
+	}
+	for i := 0; i < workers; i++ {
+		b.wg.Add(1)
+		go b.worker()
+	}
+	return b
+}
+
+// Analyze queues text and waits for its result or for ctx to end.
+func (b *MicroBatcher) Analyze(ctx context.Context, text string) (SentimentResult, error) {
+	req, err := b.submit(ctx, text)
+	if err != nil {
+		return SentimentResult{}, err
+	}
+	select {
+	case res := <-req.done:
+		return res.SentimentResult, res.Err
+	case <-ctx.Done():
+		return SentimentResult{}, ctx.Err()
+	}
+}
+
+// AnalyzeBatch queues all texts at once so they can share model runs with
+// each other and with concurrent requests, and returns results in order.
+func (b *MicroBatcher) AnalyzeBatch(ctx context.Context, texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	reqs := make([]*batchRequest, len(texts))
+	for i, text := range texts {
+		req, err := b.submit(ctx, text)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		reqs[i] = req
+	}
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		select {
+		case results[i] = <-req.done:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	return results
+}
+
+func (b *MicroBatcher) submit(ctx context.Context, text string) (*batchRequest, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return nil, ErrBatcherClosed
+	}
+
+	req := &batchRequest{text: text, enqueued: time.Now(), done: make(chan BatchResult, 1)}
+	select {
+	case b.queue <- req:
+		batchQueueDepth.Inc()
+		return req, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// worker collects up to maxBatch requests, waiting at most maxDelay after
+// the first one, and runs them as one batch.
+func (b *MicroBatcher) worker() {
+	defer b.wg.Done()
+	for {
+		first, ok := <-b.queue
+		if !ok {
+			return
+		}
+		batch := []*batchRequest{first}
+		timer := time.NewTimer(b.maxDelay)
+	collect:
+		for len(batch) < b.maxBatch {
+			select {
+			case req, ok := <-b.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		b.run(batch)
+	}
+}
+
+func (b *MicroBatcher) run(batch []*batchRequest) {
+	batchQueueDepth.Sub(float64(len(batch)))
+	batchSizeHistogram.Observe(float64(len(batch)))
+
+	texts := make([]string, len(batch))
+	now := time.Now()
+	for i, req := range batch {
+		texts[i] = req.text
+		batchQueueWait.Observe(now.Sub(req.enqueued).Seconds())
+	}
+	results := b.analyzer.AnalyzeBatch(texts)
+	for i, req := range batch {
+		req.done <- results[i]
+	}
+}
+
+// Close stops accepting requests, finishes queued ones and stops the workers.
+// The underlying analyzer is not closed.
+func (b *MicroBatcher) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	b.wg.Wait()
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoAnalyzer labels every text with the text itself and records the size
+// of each batch it runs.
+type echoAnalyzer struct {
+	mu      sync.Mutex
+	batches []int
+}
+
+func (a *echoAnalyzer) Analyze(text string) (SentimentResult, error) {
+	return SentimentResult{Label: text}, nil
+}
+
+func (a *echoAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return a.Analyze(text)
+}
+
+func (a *echoAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	a.mu.Lock()
+	a.batches = append(a.batches, len(texts))
+	a.mu.Unlock()
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = a.Analyze(text)
+	}
+	return results
+}
+
+func (a *echoAnalyzer) Close() error { return nil }
+
+func (a *echoAnalyzer) batchSizes() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]int(nil), a.batches...)
+}
+
+func TestMicroBatcherFlushesOnSize(t *testing.T) {
+	a := &echoAnalyzer{}
+	// The delay is far longer than the test, so only a full batch can run.
+	b := NewMicroBatcher(a, 4, time.Hour, 1)
+	defer b.Close()
+
+	texts := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	done := make(chan []BatchResult)
+	go func() { done <- b.AnalyzeBatch(context.Background(), texts) }()
+	var results []BatchResult
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("full batches were not run before the delay")
+	}
+
+	for i, r := range results {
+		if r.Err != nil || r.Label != texts[i] {
+			t.Errorf("result %d = %q, %v, want %q", i, r.Label, r.Err, texts[i])
+		}
+	}
+	if got := a.batchSizes(); len(got) != 2 || got[0] != 4 || got[1] != 4 {
+		t.Errorf("batch sizes = %v, want [4 4]", got)
+	}
+}
+
+func TestMicroBatcherFlushesOnTimeout(t *testing.T) {
+	a := &echoAnalyzer{}
+	const delay = 50 * time.Millisecond
+	b := NewMicroBatcher(a, 16, delay, 1)
+	defer b.Close()
+
+	start := time.Now()
+	r, err := b.Analyze(context.Background(), "alone")
+	if err != nil || r.Label != "alone" {
+		t.Fatalf("result = %q, %v", r.Label, err)
+	}
+	if waited := time.Since(start); waited < delay {
+		t.Errorf("a lone text ran after %v, want it held for %v", waited, delay)
+	}
+	if got := a.batchSizes(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("batch sizes = %v, want [1]", got)
+	}
+}
+
+func TestMicroBatcherClose(t *testing.T) {
+	b := NewMicroBatcher(&echoAnalyzer{}, 4, time.Millisecond, 2)
+	b.Close()
+	b.Close()
+	if _, err := b.Analyze(context.Background(), "late"); !errors.Is(err, ErrBatcherClosed) {
+		t.Errorf("err = %v, want %v", err, ErrBatcherClosed)
+	}
+}
@@ -0,0 +1,415 @@
+package model
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	cacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_cache_lookups_total",
+			Help: "Sentiment cache lookups per tier and result (hit or miss)",
+		},
+		[]string{"tier", "result"},
+	)
+	modelFilesChanged = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sentiment_model_files_changed_total",
+			Help: "Number of times the files of the served sentiment model changed on disk without a restart or swap",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheLookups, modelFilesChanged)
+}
+
+// CacheConfig enables the result cache in configs/model.yaml.
+type CacheConfig struct {
+	Size          int           `yaml:"size"`           // in-memory entries, 0 disables the memory tier
+	Dir           string        `yaml:"dir"`            // on-disk tier, empty disables it
+	CheckInterval time.Duration `yaml:"check_interval"` // how often model files are checked for changes
+	MaxAge        time.Duration `yaml:"max_age"`        // disk entries older than this are evicted, default 7 days
+}
+
+// cacheSweepInterval is how often the disk tier is swept for expired entries.
+const cacheSweepInterval = time.Hour
+
+// Enabled reports whether either cache tier is configured.
+func (c CacheConfig) Enabled() bool {
+	return c.Size > 0 || c.Dir != ""
+}
+
+// CachedAnalyzer serves repeated texts from a two-tier cache: an in-memory
+// LRU in front of one JSON file per result on disk. Entries are keyed by a
+// hash of the normalized text and namespaced by the model's name, version
+// and a fingerprint of its files when the cache was opened, so a restart or
+// swap onto a new version or replaced ONNX, vocab or calibration file starts
+// from an empty namespace and removes the old one. Disk entries older than
+// MaxAge are misses and are swept away hourly.
+type CachedAnalyzer struct {
+	inner SentimentAnalyzer
+	def   ModelDefinition
+	cfg   CacheConfig
+
+	mu          sync.Mutex
+	namespace   string
+	lastChecked time.Time
+	lastSwept   time.Time
+	sweeps      sync.WaitGroup
+	changed     string     // fingerprint of the files on disk last reported as changed
+	lru         *list.List // of *cacheEntry, most recently used first
+	entries     map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key    string
+	result SentimentResult
+}
+
+var (
+	cacheDirsMu sync.Mutex
+	cacheDirs   = make(map[string]int) // open caches per namespace directory
+)
+
+// NewCachedAnalyzer wraps inner, which must be the analyzer for def.
+// Stale on-disk entries of earlier model versions are removed, except those
+// of caches still open in this process, such as the old model's during a
+// swap.
+func NewCachedAnalyzer(inner SentimentAnalyzer, def ModelDefinition, cfg CacheConfig) (*CachedAnalyzer, error) {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 30 * time.Second
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 7 * 24 * time.Hour
+	}
+	c := &CachedAnalyzer{
+		inner:       inner,
+		def:         def,
+		cfg:         cfg,
+		namespace:   modelNamespace(def),
+		lastChecked: time.Now(),
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+	}
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(c.modelDir(), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create sentiment cache directory: %w", err)
+		}
+		cacheDirsMu.Lock()
+		cacheDirs[c.namespaceDir()]++
+		c.removeStaleNamespaces()
+		cacheDirsMu.Unlock()
+		c.sweepLocked()
+	}
+	return c, nil
+}
+
+// Analyze returns the cached result for text or scores it with the wrapped analyzer.
+func (c *CachedAnalyzer) Analyze(text string) (SentimentResult, error) {
+	return c.cached("short", text, c.inner.Analyze)
+}
+
+// AnalyzeLong is like Analyze for long texts. Long and short results are cached separately.
+func (c *CachedAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return c.cached("long", text, c.inner.AnalyzeLong)
+}
+
+// AnalyzeBatch serves cached texts directly and sends only the misses to the
+// wrapped analyzer, in one batch.
+func (c *CachedAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	keys := make([]string, len(texts))
+	var missTexts []string
+	var missIdx []int
+	for i, text := range texts {
+		keys[i] = c.key("short", text)
+		if res, found := c.get(keys[i]); found {
+			results[i].SentimentResult = res
+			continue
+		}
+		missTexts = append(missTexts, text)
+		missIdx = append(missIdx, i)
+	}
+	if len(missTexts) == 0 {
+		return results
+	}
+
+	for j, res := range c.inner.AnalyzeBatch(missTexts) {
+		i := missIdx[j]
+		results[i] = res
+		if res.Err == nil {
+			c.put(keys[i], res.SentimentResult)
+		}
+	}
+	return results
+}
+
+// Explain explains with the wrapped analyzer; explanations aren't cached.
+func (c *CachedAnalyzer) Explain(text string) (Explanation, error) {
+	return Explain(c.inner, text)
+}
+
+// Close closes the wrapped analyzer. Disk entries are kept for the next run
+// unless another version of the model is cached in this process, as after a
+// swap.
+func (c *CachedAnalyzer) Close() error {
+	c.sweeps.Wait()
+	if c.cfg.Dir != "" {
+		cacheDirsMu.Lock()
+		dir := c.namespaceDir()
+		if cacheDirs[dir]--; cacheDirs[dir] == 0 {
+			delete(cacheDirs, dir)
+			for open := range cacheDirs {
+				if filepath.Dir(open) == c.modelDir() {
+					os.RemoveAll(dir)
+					break
+				}
+			}
+		}
+		cacheDirsMu.Unlock()
+	}
+	return c.inner.Close()
+}
+
+func (c *CachedAnalyzer) cached(mode, text string, analyze func(string) (SentimentResult, error)) (SentimentResult, error) {
+	key := c.key(mode, text)
+	if res, found := c.get(key); found {
+		return res, nil
+	}
+	res, err := analyze(text)
+	if err != nil {
+		return res, err
+	}
+	c.put(key, res)
+	return res, nil
+}
+
+// key hashes the scoring mode and normalized text. The model namespace is
+// not part of the key; it selects the directory and is checked on lookup.
+func (c *CachedAnalyzer) key(mode, text string) string {
+	sum := sha256.Sum256([]byte(mode + "\x00" + normalizeCacheText(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *CachedAnalyzer) get(key string) (SentimentResult, bool) {
+	c.mu.Lock()
+	c.checkModelLocked()
+	if c.cfg.Size > 0 {
+		if el, found := c.entries[key]; found {
+			c.lru.MoveToFront(el)
+			res := el.Value.(*cacheEntry).result
+			c.mu.Unlock()
+			cacheLookups.WithLabelValues("memory", "hit").Inc()
+			res.Cached = true
+			return res, true
+		}
+		cacheLookups.WithLabelValues("memory", "miss").Inc()
+	}
+	namespace := c.namespace
+	c.mu.Unlock()
+
+	if c.cfg.Dir == "" {
+		return SentimentResult{}, false
+	}
+	// A file that can't be read or decoded is treated as a miss and is
+	// overwritten when the text is scored again.
+	path := c.entryPath(namespace, key)
+	info, err := os.Stat(path)
+	if err == nil && time.Since(info.ModTime()) > c.cfg.MaxAge {
+		os.Remove(path)
+		err = os.ErrNotExist
+	}
+	var raw []byte
+	if err == nil {
+		raw, err = os.ReadFile(path)
+	}
+	var res SentimentResult
+	if err != nil || json.Unmarshal(raw, &res) != nil {
+		cacheLookups.WithLabelValues("disk", "miss").Inc()
+		return SentimentResult{}, false
+	}
+	cacheLookups.WithLabelValues("disk", "hit").Inc()
+	c.putMemory(namespace, key, res)
+	res.Cached = true
+	return res, true
+}
+
+func (c *CachedAnalyzer) put(key string, res SentimentResult) {
+	c.mu.Lock()
+	namespace := c.namespace
+	if c.cfg.Dir != "" && time.Since(c.lastSwept) >= cacheSweepInterval {
+		c.sweepLocked()
+	}
+	c.mu.Unlock()
+
+	c.putMemory(namespace, key, res)
+	if c.cfg.Dir != "" {
+		// Failing to persist only costs a recomputation later.
+		_ = c.writeEntry(namespace, key, res)
+	}
+}
+
+func (c *CachedAnalyzer) putMemory(namespace, key string, res SentimentResult) {
+	if c.cfg.Size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if namespace != c.namespace {
+		return // the model changed while this result was computed
+	}
+	if el, found := c.entries[key]; found {
+		el.Value.(*cacheEntry).result = res
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, result: res})
+	for c.lru.Len() > c.cfg.Size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// writeEntry writes through a temporary file so concurrent readers never
+// see a partial entry.
+func (c *CachedAnalyzer) writeEntry(namespace, key string, res SentimentResult) error {
+	path := c.entryPath(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkModelLocked re-fingerprints the model files every CheckInterval.
+// The wrapped analyzer keeps scoring with the files it loaded, so cached
+// results stay valid for it and nothing is dropped: a change is reported
+// once, and the cache of the analyzer opened by a restart or Swap uses the
+// new namespace. c.mu must be held.
+func (c *CachedAnalyzer) checkModelLocked() {
+	if time.Since(c.lastChecked) < c.cfg.CheckInterval {
+		return
+	}
+	c.lastChecked = time.Now()
+	namespace := modelNamespace(c.def)
+	if namespace == c.namespace || namespace == c.changed {
+		return
+	}
+
+	c.changed = namespace
+	modelFilesChanged.Inc()
+	fmt.Printf("Model files for %q changed on disk; still serving the loaded model, restart or swap to use them\n", c.def.Name)
+}
+
+// sweepLocked starts removing the disk entries older than MaxAge in the
+// background. c.mu must be held or c not yet shared.
+func (c *CachedAnalyzer) sweepLocked() {
+	c.lastSwept = time.Now()
+	c.sweeps.Add(1)
+	go func() {
+		defer c.sweeps.Done()
+		c.evictExpired()
+	}()
+}
+
+// evictExpired removes the files older than MaxAge from the namespace
+// directory: expired entries and temporary files of interrupted writes.
+func (c *CachedAnalyzer) evictExpired() {
+	cutoff := time.Now().Add(-c.cfg.MaxAge)
+	filepath.WalkDir(c.namespaceDir(), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (c *CachedAnalyzer) modelDir() string {
+	return filepath.Join(c.cfg.Dir, c.def.Name)
+}
+
+func (c *CachedAnalyzer) namespaceDir() string {
+	return filepath.Join(c.modelDir(), c.namespace)
+}
+
+// entryPath spreads entries over 256 subdirectories by key prefix.
+func (c *CachedAnalyzer) entryPath(namespace, key string) string {
+	return filepath.Join(c.modelDir(), namespace, key[:2], key+".json")
+}
+
+// removeStaleNamespaces deletes on-disk entries of every other version of
+// the model that no open cache uses. cacheDirsMu must be held.
+func (c *CachedAnalyzer) removeStaleNamespaces() {
+	dirs, err := os.ReadDir(c.modelDir())
+	if err != nil {
+		return
+	}
+	for _, d := range dirs {
+		path := filepath.Join(c.modelDir(), d.Name())
+		if d.IsDir() && cacheDirs[path] == 0 {
+			os.RemoveAll(path)
+		}
+	}
+}
+
+// modelNamespace identifies the model's results: its version plus the size
+// and modification time of its files (model, vocab and calibration, and
+// those of ensemble members), so replacing a file in place changes it.
+// Files that can't be read contribute only their path.
+func modelNamespace(def ModelDefinition) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", def.Name, def.Type, def.Version)
+	for _, path := range []string{def.Path, def.Vocab, def.CalibrationPath} {
+		if path == "" {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00", path)
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(h, "%d\x00%d\x00", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	for _, member := range def.memberDefs {
+		fmt.Fprintf(h, "%s\x00", modelNamespace(member))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// normalizeCacheText makes syndicated copies of a headline share a key:
+// Unicode NFC and collapsed whitespace. Case is kept because cased models
+// score "US" and "us" differently.
+func normalizeCacheText(text string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+}
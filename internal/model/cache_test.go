@@ -0,0 +1,183 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingAnalyzer scores every text as neutral and counts its calls.
+type countingAnalyzer struct {
+	calls int
+}
+
+func (a *countingAnalyzer) Analyze(text string) (SentimentResult, error) {
+	a.calls++
+	return SentimentResult{Label: "neutral", Confidence: 1}, nil
+}
+
+func (a *countingAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return a.Analyze(text)
+}
+
+func (a *countingAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = a.Analyze(text)
+	}
+	return results
+}
+
+func (a *countingAnalyzer) Close() error { return nil }
+
+func TestCachedAnalyzerKeepsEntriesWhenFilesChange(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.onnx")
+	if err := os.WriteFile(modelPath, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	def := ModelDefinition{Name: "test", Type: ModelTypeONNX, Version: "1", Path: modelPath}
+	inner := &countingAnalyzer{}
+	c, err := NewCachedAnalyzer(inner, def, CacheConfig{Size: 10, Dir: filepath.Join(dir, "cache"), CheckInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespace := c.namespace
+
+	if _, err := c.Analyze("Infosys beats estimates"); err != nil {
+		t.Fatal(err)
+	}
+	// Replacing the file doesn't change what the loaded model scores, so
+	// the cached result is still served from the same namespace.
+	if err := os.WriteFile(modelPath, []byte("version two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	res, err := c.Analyze("Infosys  beats estimates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Cached || inner.calls != 1 {
+		t.Errorf("cached = %v after %d calls, want a cache hit after 1 call", res.Cached, inner.calls)
+	}
+	if c.namespace != namespace {
+		t.Error("namespace changed while the same model is loaded")
+	}
+
+	// A cache opened for the replaced files, as by a swap, starts empty. The
+	// old entries stay while the old cache is open and go when it closes.
+	c2, err := NewCachedAnalyzer(inner, def, CacheConfig{Dir: filepath.Join(dir, "cache")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	if c2.namespace == namespace {
+		t.Fatal("namespace unchanged for replaced model files")
+	}
+	if res, _ := c2.Analyze("Infosys beats estimates"); res.Cached {
+		t.Error("result of the old files served by the new cache")
+	}
+	oldDir := filepath.Join(dir, "cache", "test", namespace)
+	if _, err := os.Stat(oldDir); err != nil {
+		t.Errorf("namespace of the open cache removed: %v", err)
+	}
+	c.Close()
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("old namespace not removed on close: %v", err)
+	}
+}
+
+func TestCachedAnalyzerKeepsEntriesOnClose(t *testing.T) {
+	dir := t.TempDir()
+	def := ModelDefinition{Name: "test", Type: ModelTypeLexicon, Version: "1"}
+	cfg := CacheConfig{Dir: filepath.Join(dir, "cache")}
+	c, err := NewCachedAnalyzer(&countingAnalyzer{}, def, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Analyze("Infosys beats estimates")
+	c.Close()
+
+	// The next run finds the entry on disk.
+	inner := &countingAnalyzer{}
+	c, err = NewCachedAnalyzer(inner, def, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if res, _ := c.Analyze("Infosys beats estimates"); !res.Cached || inner.calls != 0 {
+		t.Errorf("cached = %v, calls = %d after reopening", res.Cached, inner.calls)
+	}
+}
+
+func TestCachedAnalyzerVersionBumpMisses(t *testing.T) {
+	dir := t.TempDir()
+	cfg := CacheConfig{Size: 10, Dir: filepath.Join(dir, "cache")}
+	v1 := ModelDefinition{Name: "test", Type: ModelTypeLexicon, Version: "1"}
+	c, err := NewCachedAnalyzer(&countingAnalyzer{}, v1, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Analyze("Infosys beats estimates")
+	oldDir := c.namespaceDir()
+	c.Close()
+
+	v2 := v1
+	v2.Version = "2"
+	inner := &countingAnalyzer{}
+	c, err = NewCachedAnalyzer(inner, v2, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if res, _ := c.Analyze("Infosys beats estimates"); res.Cached || inner.calls != 1 {
+		t.Errorf("cached = %v, calls = %d for a new version", res.Cached, inner.calls)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("entries of the superseded version not removed: %v", err)
+	}
+}
+
+func TestCachedAnalyzerEvictsExpiredEntries(t *testing.T) {
+	dir := t.TempDir()
+	def := ModelDefinition{Name: "test", Type: ModelTypeLexicon, Version: "1"}
+	inner := &countingAnalyzer{}
+	c, err := NewCachedAnalyzer(inner, def, CacheConfig{Dir: filepath.Join(dir, "cache"), MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	texts := []string{"Infosys beats estimates", "TCS misses estimates", "Wipro in line"}
+	for _, text := range texts {
+		c.Analyze(text)
+	}
+	age := func(text string) string {
+		path := c.entryPath(c.namespace, c.key("short", text))
+		old := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// An expired entry is a miss and is rewritten.
+	age(texts[0])
+	if res, _ := c.Analyze(texts[0]); res.Cached || inner.calls != 4 {
+		t.Errorf("cached = %v, calls = %d for an expired entry", res.Cached, inner.calls)
+	}
+	if res, _ := c.Analyze(texts[0]); !res.Cached {
+		t.Error("rescored entry not cached again")
+	}
+
+	// The sweep removes expired entries without a lookup.
+	expired := age(texts[1])
+	c.evictExpired()
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expired entry not evicted: %v", err)
+	}
+	if res, _ := c.Analyze(texts[2]); !res.Cached {
+		t.Error("fresh entry evicted")
+	}
+}
@@ -0,0 +1,306 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Calibration methods.
+const (
+	CalibrateTemperature = "temperature" // one temperature dividing every log-probability
+	CalibratePlatt       = "platt"       // per-class sigmoid on the log-probability, renormalized
+)
+
+// minProb keeps log-probabilities finite.
+const minProb = 1e-7
+
+// PlattParams are the slope and intercept of one class's sigmoid.
+type PlattParams struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// Calibration maps a model's raw probabilities to calibrated ones. It is
+// fitted on a labelled validation set by FitCalibration, saved as JSON at the
+// model's calibration path and applied to every result once loaded.
+type Calibration struct {
+	Method      string        `json:"method"`
+	Labels      []string      `json:"labels"`
+	Temperature float64       `json:"temperature,omitempty"`
+	Platt       []PlattParams `json:"platt,omitempty"` // ordered like Labels
+
+	FittedAt          time.Time        `json:"fitted_at"`
+	Dataset           string           `json:"dataset,omitempty"`
+	Examples          int              `json:"examples"`
+	ECEBefore         float64          `json:"ece_before"`
+	ECEAfter          float64          `json:"ece_after"`
+	NLLBefore         float64          `json:"nll_before"` // mean negative log-likelihood of the gold label
+	NLLAfter          float64          `json:"nll_after"`
+	ReliabilityBefore []ReliabilityBin `json:"reliability_before"`
+	ReliabilityAfter  []ReliabilityBin `json:"reliability_after"`
+}
+
+// LoadCalibration reads a calibration saved by Save.
+func LoadCalibration(path string) (*Calibration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Calibration
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse calibration %s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("calibration %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the calibration as JSON, creating the directory if needed.
+func (c *Calibration) Save(path string) error {
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create calibration directory: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write calibration: %w", err)
+	}
+	return nil
+}
+
+func (c *Calibration) validate() error {
+	switch c.Method {
+	case CalibrateTemperature:
+		if c.Temperature <= 0 {
+			return fmt.Errorf("temperature must be positive, got %v", c.Temperature)
+		}
+	case CalibratePlatt:
+		if len(c.Platt) != len(c.Labels) {
+			return fmt.Errorf("%d platt parameters for %d labels", len(c.Platt), len(c.Labels))
+		}
+	default:
+		return fmt.Errorf("unknown calibration method %q", c.Method)
+	}
+	return nil
+}
+
+// Apply returns calibrated probabilities for raw ones ordered like c.Labels.
+func (c *Calibration) Apply(probs []float32) []float32 {
+	switch c.Method {
+	case CalibrateTemperature:
+		logits := make([]float32, len(probs))
+		for i, p := range probs {
+			logits[i] = float32(math.Log(math.Max(float64(p), minProb)) / c.Temperature)
+		}
+		return softmax(logits)
+
+	case CalibratePlatt:
+		out := make([]float32, len(probs))
+		var sum float32
+		for i, p := range probs {
+			x := math.Log(math.Max(float64(p), minProb))
+			out[i] = float32(sigmoid(c.Platt[i].A*x + c.Platt[i].B))
+			sum += out[i]
+		}
+		if sum == 0 {
+			return probs
+		}
+		for i := range out {
+			out[i] /= sum
+		}
+		return out
+	}
+	return probs
+}
+
+// FitCalibration scores the examples with analyzer, which must run def
+// without a calibration, fits the given method to the gold labels and
+// measures reliability before and after. Examples whose label the model
+// doesn't have are skipped.
+func FitCalibration(def ModelDefinition, analyzer SentimentAnalyzer, examples []LabeledExample, method string) (*Calibration, error) {
+	if def.Calibration != nil {
+		return nil, errors.New("fit calibration on the uncalibrated model")
+	}
+
+	texts := make([]string, 0, len(examples))
+	var gold []int
+	for _, ex := range examples {
+		if idx := slices.Index(def.Labels, ex.Label); idx >= 0 {
+			texts = append(texts, ex.Text)
+			gold = append(gold, idx)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no examples are labelled with one of %v", def.Labels)
+	}
+
+	var probs [][]float32
+	var y []int
+	for i, res := range analyzer.AnalyzeBatch(texts) {
+		if res.Err != nil {
+			continue
+		}
+		p := make([]float32, len(def.Labels))
+		for c, label := range def.Labels {
+			p[c] = res.Probabilities[label]
+		}
+		probs = append(probs, p)
+		y = append(y, gold[i])
+	}
+	if len(probs) == 0 {
+		return nil, errors.New("the analyzer failed on every example")
+	}
+
+	c := &Calibration{Method: method, Labels: def.Labels, FittedAt: time.Now(), Examples: len(probs)}
+	switch method {
+	case CalibrateTemperature:
+		c.Temperature = fitTemperature(probs, y)
+	case CalibratePlatt:
+		c.Platt = make([]PlattParams, len(def.Labels))
+		for k := range def.Labels {
+			c.Platt[k] = fitPlatt(probs, y, k)
+		}
+	default:
+		return nil, fmt.Errorf("unknown calibration method %q", method)
+	}
+
+	calibrated := make([][]float32, len(probs))
+	for i, p := range probs {
+		calibrated[i] = c.Apply(p)
+	}
+	c.NLLBefore, c.NLLAfter = meanNLL(probs, y), meanNLL(calibrated, y)
+	c.ReliabilityBefore, c.ECEBefore = reliabilityOf(def, probs, y)
+	c.ReliabilityAfter, c.ECEAfter = reliabilityOf(def, calibrated, y)
+	return c, nil
+}
+
+// fitTemperature minimizes the negative log-likelihood over log T with a
+// golden-section search; the NLL is unimodal in T.
+func fitTemperature(probs [][]float32, y []int) float64 {
+	nll := func(logT float64) float64 {
+		c := Calibration{Method: CalibrateTemperature, Temperature: math.Exp(logT)}
+		total := 0.0
+		for i, p := range probs {
+			total -= math.Log(math.Max(float64(c.Apply(p)[y[i]]), minProb))
+		}
+		return total / float64(len(probs))
+	}
+
+	lo, hi := math.Log(0.05), math.Log(20)
+	ratio := (math.Sqrt(5) - 1) / 2
+	a, b := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	fa, fb := nll(a), nll(b)
+	for hi-lo > 1e-4 {
+		if fa < fb {
+			hi, b, fb = b, a, fa
+			a = hi - ratio*(hi-lo)
+			fa = nll(a)
+		} else {
+			lo, a, fa = a, b, fb
+			b = lo + ratio*(hi-lo)
+			fb = nll(b)
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// fitPlatt fits a one-vs-rest logistic regression of "gold is class k" on
+// log p_k with Newton's method and a little L2 regularization towards the
+// identity (a=1, b=0) to keep rare classes stable.
+func fitPlatt(probs [][]float32, y []int, k int) PlattParams {
+	const lambda = 1e-3
+	a, b := 1.0, 0.0
+	for iter := 0; iter < 50; iter++ {
+		var ga, gb, haa, hab, hbb float64
+		for i, p := range probs {
+			x := math.Log(math.Max(float64(p[k]), minProb))
+			t := 0.0
+			if y[i] == k {
+				t = 1
+			}
+			q := sigmoid(a*x + b)
+			ga += (q - t) * x
+			gb += q - t
+			w := q * (1 - q)
+			haa += w * x * x
+			hab += w * x
+			hbb += w
+		}
+		ga += lambda * (a - 1)
+		gb += lambda * b
+		haa += lambda
+		hbb += lambda
+
+		det := haa*hbb - hab*hab
+		if det <= 1e-12 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+		a, b = a-da, b-db
+		if math.Abs(da) < 1e-8 && math.Abs(db) < 1e-8 {
+			break
+		}
+	}
+	return PlattParams{A: a, B: b}
+}
+
+func meanNLL(probs [][]float32, y []int) float64 {
+	total := 0.0
+	for i, p := range probs {
+		total -= math.Log(math.Max(float64(p[y[i]]), minProb))
+	}
+	return total / float64(len(probs))
+}
+
+func reliabilityOf(def ModelDefinition, probs [][]float32, y []int) ([]ReliabilityBin, float64) {
+	def.Calibration = nil
+	gold := make([]string, len(probs))
+	preds := make([]SentimentResult, len(probs))
+	for i, p := range probs {
+		gold[i] = def.Labels[y[i]]
+		preds[i] = newSentimentResult(def, p, false, 0)
+	}
+	return Reliability(gold, preds, 10)
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// WriteText prints the fitted parameters and the reliability before and after.
+func (c *Calibration) WriteText(w io.Writer) error {
+	switch c.Method {
+	case CalibrateTemperature:
+		fmt.Fprintf(w, "Temperature %.4f fitted on %d examples\n", c.Temperature, c.Examples)
+	case CalibratePlatt:
+		fmt.Fprintf(w, "Platt scaling fitted on %d examples\n", c.Examples)
+		for i, p := range c.Platt {
+			fmt.Fprintf(w, "  %s: a=%.4f b=%.4f\n", c.Labels[i], p.A, p.B)
+		}
+	}
+	fmt.Fprintf(w, "ECE %.4f -> %.4f | NLL %.4f -> %.4f\n", c.ECEBefore, c.ECEAfter, c.NLLBefore, c.NLLAfter)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONFIDENCE\tCOUNT BEFORE\tCONF BEFORE\tACC BEFORE\tCOUNT AFTER\tCONF AFTER\tACC AFTER")
+	for i := range c.ReliabilityBefore {
+		before, after := c.ReliabilityBefore[i], c.ReliabilityAfter[i]
+		if before.Count == 0 && after.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%.1f-%.1f\t%d\t%.4f\t%.4f\t%d\t%.4f\t%.4f\n", before.Lower, before.Upper,
+			before.Count, before.Confidence, before.Accuracy, after.Count, after.Confidence, after.Accuracy)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scoredAnalyzer returns the probabilities listed for each text.
+type scoredAnalyzer map[string]map[string]float32
+
+func (a scoredAnalyzer) Analyze(text string) (SentimentResult, error) {
+	probs, found := a[text]
+	if !found {
+		return SentimentResult{}, fmt.Errorf("no score for %q", text)
+	}
+	return SentimentResult{Probabilities: probs}, nil
+}
+
+func (a scoredAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return a.Analyze(text)
+}
+
+func (a scoredAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = a.Analyze(text)
+	}
+	return results
+}
+
+func (a scoredAnalyzer) Close() error { return nil }
+
+var calibrationLabels = []string{"negative", "neutral", "positive"}
+
+func TestFitTemperatureOverconfident(t *testing.T) {
+	// The model puts 0.9 on its prediction but is right only 60% of the time.
+	analyzer := make(scoredAnalyzer)
+	var examples []LabeledExample
+	for i := 0; i < 50; i++ {
+		text := fmt.Sprintf("headline %d", i)
+		predicted := calibrationLabels[i%3]
+		probs := map[string]float32{"negative": 0.05, "neutral": 0.05, "positive": 0.05}
+		probs[predicted] = 0.9
+		analyzer[text] = probs
+
+		gold := predicted
+		if i%5 >= 3 {
+			gold = calibrationLabels[(i+1)%3]
+		}
+		examples = append(examples, LabeledExample{Text: text, Label: gold})
+	}
+	examples = append(examples, LabeledExample{Text: "unscored", Label: "positive"}, LabeledExample{Text: "headline 0", Label: "bullish"})
+
+	def := ModelDefinition{Name: "finbert", Labels: calibrationLabels}
+	c, err := FitCalibration(def, analyzer, examples, CalibrateTemperature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Examples != 50 {
+		t.Errorf("fitted on %d examples, want 50", c.Examples)
+	}
+	if c.Temperature <= 1 {
+		t.Errorf("temperature %v does not soften an over-confident model", c.Temperature)
+	}
+	if c.NLLAfter >= c.NLLBefore || c.ECEAfter >= c.ECEBefore {
+		t.Errorf("NLL %v -> %v, ECE %v -> %v; want both lower", c.NLLBefore, c.NLLAfter, c.ECEBefore, c.ECEAfter)
+	}
+	// Calibrated confidence should land near the 60% accuracy.
+	if got := c.Apply([]float32{0.05, 0.05, 0.9})[2]; math.Abs(float64(got)-0.6) > 0.05 {
+		t.Errorf("calibrated confidence %v, want about 0.6", got)
+	}
+
+	def.Calibration = c
+	if _, err := FitCalibration(def, analyzer, examples, CalibrateTemperature); err == nil {
+		t.Error("fitted on an already calibrated model")
+	}
+}
+
+func TestFitPlattMonotone(t *testing.T) {
+	// p(positive) sweeps 0..1 and the gold label is positive about that often.
+	analyzer := make(scoredAnalyzer)
+	var examples []LabeledExample
+	for i := 0; i < 200; i++ {
+		text := fmt.Sprintf("headline %d", i)
+		p := (float32(i) + 0.5) / 200
+		analyzer[text] = map[string]float32{"negative": (1 - p) * 0.6, "neutral": (1 - p) * 0.4, "positive": p}
+
+		gold := "positive"
+		if _, frac := math.Modf(float64(i) * 0.618034); frac >= float64(p) {
+			gold = calibrationLabels[i%2]
+		}
+		examples = append(examples, LabeledExample{Text: text, Label: gold})
+	}
+
+	c, err := FitCalibration(ModelDefinition{Name: "finbert", Labels: calibrationLabels}, analyzer, examples, CalibratePlatt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range c.Platt {
+		if p.A <= 0 {
+			t.Errorf("%s: slope %v, want positive", c.Labels[i], p.A)
+		}
+	}
+	prev := float32(-1)
+	for i := 1; i < 20; i++ {
+		p := float32(i) / 20
+		got := c.Apply([]float32{(1 - p) * 0.6, (1 - p) * 0.4, p})[2]
+		if got <= prev {
+			t.Errorf("calibrated p(positive) %v at raw %v after %v", got, p, prev)
+		}
+		prev = got
+	}
+}
+
+func TestCalibrationApplySumsToOne(t *testing.T) {
+	calibrations := []*Calibration{
+		{Method: CalibrateTemperature, Temperature: 0.5},
+		{Method: CalibrateTemperature, Temperature: 1},
+		{Method: CalibrateTemperature, Temperature: 4},
+		{Method: CalibratePlatt, Platt: []PlattParams{{A: 1, B: 0}, {A: 1, B: 0}, {A: 1, B: 0}}},
+		{Method: CalibratePlatt, Platt: []PlattParams{{A: 2, B: 1}, {A: 0.5, B: -2}, {A: 1.3, B: 0.2}}},
+	}
+	inputs := [][]float32{
+		{0.2, 0.3, 0.5},
+		{0, 0, 1},
+		{0.98, 0.01, 0.01},
+		{1.0 / 3, 1.0 / 3, 1.0 / 3},
+	}
+	for _, c := range calibrations {
+		for _, probs := range inputs {
+			out := c.Apply(probs)
+			var sum float64
+			for _, p := range out {
+				if p < 0 || p > 1 {
+					t.Errorf("%s %+v: probability %v out of range", c.Method, c, p)
+				}
+				sum += float64(p)
+			}
+			if math.Abs(sum-1) > 1e-5 {
+				t.Errorf("%s %+v: Apply(%v) = %v sums to %v", c.Method, c, probs, out, sum)
+			}
+		}
+	}
+	if got := (&Calibration{Method: CalibrateTemperature, Temperature: 1}).Apply([]float32{0.2, 0.3, 0.5}); math.Abs(float64(got[2])-0.5) > 1e-6 {
+		t.Errorf("temperature 1 changed the probabilities: %v", got)
+	}
+}
+
+func TestLoadCalibrationRejectsMismatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration *Calibration
+		wantErr     string
+	}{
+		{"matching labels", &Calibration{Method: CalibrateTemperature, Labels: calibrationLabels, Temperature: 1.5}, ""},
+		{"other labels", &Calibration{Method: CalibrateTemperature, Labels: []string{"bearish", "bullish"}, Temperature: 1.5}, "is for labels [bearish bullish]"},
+		{"labels in another order", &Calibration{Method: CalibrateTemperature, Labels: []string{"positive", "neutral", "negative"}, Temperature: 1.5}, "is for labels"},
+		{"platt parameters missing", &Calibration{Method: CalibratePlatt, Labels: calibrationLabels, Platt: []PlattParams{{A: 1}}}, "1 platt parameters for 3 labels"},
+		{"no temperature", &Calibration{Method: CalibrateTemperature, Labels: calibrationLabels}, "temperature must be positive"},
+		{"unknown method", &Calibration{Method: "isotonic", Labels: calibrationLabels}, "unknown calibration method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calibration := filepath.Join(t.TempDir(), "calibration.json")
+			if err := tt.calibration.Save(calibration); err != nil {
+				t.Fatal(err)
+			}
+			path := writeModelConfig(t, "  finbert:\n    path: m.onnx\n    vocab: v.txt\n    labels: [negative, neutral, positive]\n    calibration: "+calibration+"\n")
+			cfg, err := LoadModelConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c := cfg.Models["finbert"].Calibration; c == nil || c.Temperature != 1.5 {
+				t.Errorf("calibration %+v not loaded", c)
+			}
+		})
+	}
+
+	// A calibration that has not been fitted yet leaves the model uncalibrated.
+	path := writeModelConfig(t, "  finbert:\n    path: m.onnx\n    vocab: v.txt\n    labels: [negative, neutral, positive]\n    calibration: "+filepath.Join(t.TempDir(), "missing.json")+"\n")
+	cfg, err := LoadModelConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Models["finbert"].Calibration != nil {
+		t.Error("missing calibration file loaded a calibration")
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Aggregation modes for combining per-chunk predictions of a long text.
+const (
+	AggregateMean       = "mean"       // average logits, then softmax
+	AggregateConfidence = "confidence" // average probabilities weighted by each chunk's confidence
+	AggregateMax        = "max"        // take the chunk with the strongest polarity
+)
+
+// AnalyzeLong scores texts longer than the model's max length by splitting
+// them into overlapping windows, scoring each and aggregating with the
+// model's configured aggregation mode. Per-window results are in Chunks.
+func (a *Analyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	if strings.TrimSpace(text) == "" {
+		return SentimentResult{}, ErrEmptyText
+	}
+
+	start := time.Now()
+	windows := a.tokenizer.EncodeWindows(text, *a.def.ChunkOverlap, a.def.MaxChunks)
+	var logits [][]float32
+	for i := 0; i < len(windows); i += a.def.BatchSize {
+		end := i + a.def.BatchSize
+		if end > len(windows) {
+			end = len(windows)
+		}
+		out, err := a.run(windows[i:end])
+		if err != nil {
+			return SentimentResult{}, err
+		}
+		logits = append(logits, out...)
+	}
+
+	res, err := aggregateChunks(a.def, logits, a.def.Aggregation)
+	if err != nil {
+		return SentimentResult{}, err
+	}
+	res.Truncated = windows[len(windows)-1].Truncated
+	res.Latency = time.Since(start)
+	return res, nil
+}
+
+// aggregateChunks combines per-window logits into one prediction. Windows
+// are weighted and compared on their raw probabilities, and the model's
+// calibration is applied once, to the aggregate; Chunks are calibrated each.
+func aggregateChunks(def ModelDefinition, logits [][]float32, mode string) (SentimentResult, error) {
+	uncalibrated := def
+	uncalibrated.Calibration = nil
+	chunks := make([]SentimentResult, len(logits))
+	raw := make([]SentimentResult, len(logits))
+	probs := make([][]float32, len(logits))
+	for i, l := range logits {
+		probs[i] = softmax(l)
+		chunks[i] = newSentimentResult(def, probs[i], false, 0)
+		raw[i] = newSentimentResult(uncalibrated, probs[i], false, 0)
+	}
+
+	classes := len(def.Labels)
+	var agg []float32
+	switch mode {
+	case AggregateMean, "":
+		mode = AggregateMean
+		mean := make([]float32, classes)
+		for _, l := range logits {
+			for c := range mean {
+				mean[c] += l[c] / float32(len(logits))
+			}
+		}
+		agg = softmax(mean)
+
+	case AggregateConfidence:
+		agg = make([]float32, classes)
+		var total float32
+		for i, p := range probs {
+			w := raw[i].Confidence
+			total += w
+			for c := range p {
+				agg[c] += w * p[c]
+			}
+		}
+		for c := range agg {
+			agg[c] /= total
+		}
+
+	case AggregateMax:
+		best := 0
+		for i := range raw {
+			if polarity(raw[i]) > polarity(raw[best]) {
+				best = i
+			}
+		}
+		agg = probs[best]
+
+	default:
+		return SentimentResult{}, fmt.Errorf("unknown aggregation %q", mode)
+	}
+
+	res := newSentimentResult(def, agg, false, 0)
+	res.Aggregation = mode
+	res.Chunks = chunks
+	return res, nil
+}
+
+// polarity is the magnitude of the signed score for models with positive and
+// negative labels, and the top probability otherwise.
+func polarity(r SentimentResult) float64 {
+	_, pos := r.Probabilities["positive"]
+	_, neg := r.Probabilities["negative"]
+	if !pos || !neg {
+		return float64(r.Confidence)
+	}
+	return math.Abs(float64(r.Score))
+}
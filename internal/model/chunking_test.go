@@ -0,0 +1,81 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregateChunksCalibratesOnce(t *testing.T) {
+	labels := []string{"negative", "neutral", "positive"}
+	cal := &Calibration{Method: CalibrateTemperature, Labels: labels, Temperature: 2}
+	def := ModelDefinition{Name: "finbert", Labels: labels, Calibration: cal}
+	logits := [][]float32{
+		{0, 0.5, 4}, // confidently positive
+		{1, 0.5, 0}, // unsure, leaning negative
+		{3, 0, -1},  // confidently negative
+		{0, 0.1, 0}, // neutral and flat
+	}
+	probs := make([][]float32, len(logits))
+	for i, l := range logits {
+		probs[i] = softmax(l)
+	}
+
+	// Confidence weights are the raw top probabilities, not calibrated ones.
+	weighted := make([]float32, len(labels))
+	var total float32
+	for _, p := range probs {
+		w := p[argmax(p)]
+		total += w
+		for c := range p {
+			weighted[c] += w * p[c]
+		}
+	}
+	for c := range weighted {
+		weighted[c] /= total
+	}
+	mean := make([]float32, len(labels))
+	for _, l := range logits {
+		for c := range mean {
+			mean[c] += l[c] / float32(len(logits))
+		}
+	}
+
+	tests := []struct {
+		mode string
+		raw  []float32 // aggregate before calibration
+	}{
+		{AggregateMean, softmax(mean)},
+		{AggregateConfidence, weighted},
+		{AggregateMax, probs[0]}, // |score| 0.94 beats the negative window's 0.92
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			res, err := aggregateChunks(def, logits, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := cal.Apply(tt.raw)
+			for c, label := range labels {
+				if got := res.Probability(label); math.Abs(float64(got-want[c])) > 1e-5 {
+					t.Errorf("p(%s) = %g, want %g", label, got, want[c])
+				}
+			}
+			if !res.Calibrated || res.Aggregation != tt.mode {
+				t.Errorf("calibrated %v aggregation %q", res.Calibrated, res.Aggregation)
+			}
+			for i, chunk := range res.Chunks {
+				want := cal.Apply(probs[i])
+				if got := chunk.Probability("positive"); !chunk.Calibrated || math.Abs(float64(got-want[2])) > 1e-5 {
+					t.Errorf("chunk %d p(positive) = %g calibrated %v, want %g calibrated", i, got, chunk.Calibrated, want[2])
+				}
+			}
+		})
+	}
+}
+
+func TestAggregateChunksUnknownMode(t *testing.T) {
+	def := ModelDefinition{Labels: []string{"negative", "neutral", "positive"}}
+	if _, err := aggregateChunks(def, [][]float32{{0, 0, 1}}, "median"); err == nil {
+		t.Error("unknown aggregation mode was accepted")
+	}
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Activations of ModelDefinition.
+const (
+	ActivationSoftmax = "softmax" // one label per text, scores sum to 1
+	ActivationSigmoid = "sigmoid" // independent labels, for multi-label models
+)
+
+// ErrClassifierClosed is returned by a TextClassifier after Close.
+var ErrClassifierClosed = errors.New("text classifier is closed")
+
+var (
+	classifierPredictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "classifier_predictions_total",
+			Help: "Labels predicted by text classifiers, by model and label (none when no label reached the threshold)",
+		},
+		[]string{"model", "label"},
+	)
+	classifierDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "classifier_inference_duration_seconds",
+			Help:    "Duration of text classifier calls, by mode (single, batch)",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms to ~4s
+		},
+		[]string{"model", "mode"},
+	)
+	classifierErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "classifier_inference_errors_total",
+			Help: "Texts a text classifier could not score",
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(classifierPredictions, classifierDuration, classifierErrors)
+}
+
+// ClassifierResult is the prediction of a TextClassifier for one text.
+type ClassifierResult struct {
+	// Label is the best label: the argmax for softmax models, the highest
+	// scoring label at or above the threshold for sigmoid models, empty if
+	// there is none.
+	Label      string             `json:"label"`
+	Labels     []string           `json:"labels"`     // every predicted label, best first
+	Confidence float32            `json:"confidence"` // score of Label
+	Scores     map[string]float32 `json:"scores"`     // every label
+
+	Model        string        `json:"model"`
+	ModelVersion string        `json:"model_version,omitempty"`
+	Truncated    bool          `json:"truncated"` // input was longer than the model's max length
+	Latency      time.Duration `json:"latency_ns"`
+}
+
+// Has reports whether label was predicted.
+func (r ClassifierResult) Has(label string) bool {
+	for _, l := range r.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifierBatchResult is the outcome for one text passed to ClassifyBatch.
+type ClassifierBatchResult struct {
+	ClassifierResult
+	Err error
+}
+
+// TextClassifier hosts any BERT-style ONNX text classifier described by a
+// ModelDefinition, such as an event-type, relevance or spam model, with the
+// same session pool and batching as the sentiment Analyzer. It is safe for
+// concurrent callers; Close releases all native resources.
+type TextClassifier struct {
+	def       ModelDefinition
+	tokenizer *WordPieceTokenizer
+	runner    *onnxRunner
+}
+
+// NewTextClassifier initializes ONNX Runtime, checks the model file against
+// its definition, loads the tokenizer and creates the session pool.
+func NewTextClassifier(def ModelDefinition) (*TextClassifier, error) {
+	def.applyDefaults()
+	if def.Type != ModelTypeONNX {
+		return nil, fmt.Errorf("classifier %q: only onnx classifiers are supported, got %q", def.Name, def.Type)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("classifier %q: %w", def.Name, err)
+	}
+	tok, err := loadONNXModel(def)
+	if err != nil {
+		return nil, err
+	}
+	runner, err := newONNXRunner(def, ErrClassifierClosed)
+	if err != nil {
+		return nil, err
+	}
+	return &TextClassifier{def: def, tokenizer: tok, runner: runner}, nil
+}
+
+// Definition returns the definition of the classifier's model.
+func (c *TextClassifier) Definition() ModelDefinition {
+	return c.def
+}
+
+// Classify returns the prediction for text.
+func (c *TextClassifier) Classify(text string) (ClassifierResult, error) {
+	start := time.Now()
+	defer func() {
+		classifierDuration.WithLabelValues(c.def.Name, "single").Observe(time.Since(start).Seconds())
+	}()
+	if strings.TrimSpace(text) == "" {
+		classifierErrors.WithLabelValues(c.def.Name).Inc()
+		return ClassifierResult{}, ErrEmptyText
+	}
+	input := c.tokenizer.Encode(text)
+	logits, err := c.runner.run([]TokenizedOutput{input})
+	if err != nil {
+		classifierErrors.WithLabelValues(c.def.Name).Inc()
+		return ClassifierResult{}, err
+	}
+	res := c.newResult(logits[0], input.Truncated, time.Since(start))
+	c.observe(res)
+	return res, nil
+}
+
+// ClassifyBatch classifies texts in model runs of up to BatchSize texts and
+// returns one result per text, in order. A failing run only marks its own
+// texts as failed. Each result's latency is that of the run it was part of.
+func (c *TextClassifier) ClassifyBatch(texts []string) []ClassifierBatchResult {
+	start := time.Now()
+	results := make([]ClassifierBatchResult, len(texts))
+	c.runner.runBatches(c.tokenizer, texts, func(idx []int, inputs []TokenizedOutput, logits [][]float32, latency time.Duration, err error) {
+		for j, i := range idx {
+			if err != nil {
+				results[i].Err = err
+				classifierErrors.WithLabelValues(c.def.Name).Inc()
+				continue
+			}
+			results[i].ClassifierResult = c.newResult(logits[j], inputs[j].Truncated, latency)
+			c.observe(results[i].ClassifierResult)
+		}
+	})
+	classifierDuration.WithLabelValues(c.def.Name, "batch").Observe(time.Since(start).Seconds())
+	return results
+}
+
+// Close waits for in-flight calls to finish and destroys all sessions and tensors.
+func (c *TextClassifier) Close() error {
+	c.runner.close()
+	return nil
+}
+
+// newResult turns one row of logits into a result according to the model's
+// activation.
+func (c *TextClassifier) newResult(logits []float32, truncated bool, latency time.Duration) ClassifierResult {
+	scores := softmax(logits)
+	if c.def.Activation == ActivationSigmoid {
+		for i, v := range logits {
+			scores[i] = float32(sigmoid(float64(v)))
+		}
+	}
+
+	r := ClassifierResult{
+		Scores:       make(map[string]float32, len(scores)),
+		Model:        c.def.Name,
+		ModelVersion: c.def.Version,
+		Truncated:    truncated,
+		Latency:      latency,
+	}
+	for i, s := range scores {
+		r.Scores[c.def.Labels[i]] = s
+	}
+
+	if c.def.Activation == ActivationSigmoid {
+		var predicted []int
+		for i, s := range scores {
+			if s >= c.def.Threshold {
+				predicted = append(predicted, i)
+			}
+		}
+		sort.SliceStable(predicted, func(a, b int) bool { return scores[predicted[a]] > scores[predicted[b]] })
+		for _, i := range predicted {
+			r.Labels = append(r.Labels, c.def.Labels[i])
+		}
+	} else {
+		r.Labels = []string{c.def.Labels[argmax(scores)]}
+	}
+	if len(r.Labels) > 0 {
+		r.Label = r.Labels[0]
+		r.Confidence = r.Scores[r.Label]
+	}
+	return r
+}
+
+func (c *TextClassifier) observe(r ClassifierResult) {
+	if len(r.Labels) == 0 {
+		classifierPredictions.WithLabelValues(c.def.Name, "none").Inc()
+	}
+	for _, l := range r.Labels {
+		classifierPredictions.WithLabelValues(c.def.Name, l).Inc()
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTextClassifierNewResult(t *testing.T) {
+	sigmoidOf := func(x float64) float32 { return float32(1 / (1 + math.Exp(-x))) }
+	tests := []struct {
+		name       string
+		activation string
+		threshold  float32
+		logits     []float32
+		labels     []string // predicted, best first
+		confidence float32
+	}{
+		{"softmax argmax", ActivationSoftmax, 0.5, []float32{0, 2, 1}, []string{"earnings"}, 0.66524094},
+		{"softmax tie takes the first", ActivationSoftmax, 0.5, []float32{1, 1, 0}, []string{"deal"}, 0.42231882},
+		{"softmax ignores the threshold", ActivationSoftmax, 0.9, []float32{0.1, 0, 0}, []string{"deal"}, 0.35591307},
+		{"sigmoid over threshold", ActivationSigmoid, 0.5, []float32{2, -2, -1}, []string{"deal"}, sigmoidOf(2)},
+		{"sigmoid multi-label best first", ActivationSigmoid, 0.5, []float32{0.5, -1, 3}, []string{"litigation", "deal"}, sigmoidOf(3)},
+		{"sigmoid at threshold", ActivationSigmoid, 0.5, []float32{-1, 0, -1}, []string{"earnings"}, 0.5},
+		{"sigmoid custom threshold", ActivationSigmoid, 0.9, []float32{2, 2.5, 1}, []string{"earnings"}, sigmoidOf(2.5)},
+		{"sigmoid none", ActivationSigmoid, 0.5, []float32{-1, -2, -0.1}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &TextClassifier{def: ModelDefinition{
+				Name:       "events",
+				Version:    "v2",
+				Labels:     []string{"deal", "earnings", "litigation"},
+				Activation: tt.activation,
+				Threshold:  tt.threshold,
+			}}
+			logits := append([]float32(nil), tt.logits...)
+			r := c.newResult(logits, true, 3*time.Millisecond)
+
+			if !reflect.DeepEqual(logits, tt.logits) {
+				t.Errorf("logits modified: %v", logits)
+			}
+			if !reflect.DeepEqual(r.Labels, tt.labels) {
+				t.Errorf("labels = %v, want %v", r.Labels, tt.labels)
+			}
+			wantLabel := ""
+			if len(tt.labels) > 0 {
+				wantLabel = tt.labels[0]
+			}
+			if r.Label != wantLabel || math.Abs(float64(r.Confidence-tt.confidence)) > 1e-6 {
+				t.Errorf("label %q with confidence %v, want %q with %v", r.Label, r.Confidence, wantLabel, tt.confidence)
+			}
+			if len(r.Scores) != 3 {
+				t.Errorf("scores %v, want one per label", r.Scores)
+			}
+			if tt.activation == ActivationSoftmax {
+				var sum float32
+				for _, s := range r.Scores {
+					sum += s
+				}
+				if math.Abs(float64(sum-1)) > 1e-6 {
+					t.Errorf("softmax scores sum to %v", sum)
+				}
+			} else {
+				for i, label := range c.def.Labels {
+					if want := sigmoidOf(float64(tt.logits[i])); math.Abs(float64(r.Scores[label]-want)) > 1e-6 {
+						t.Errorf("score of %s = %v, want %v", label, r.Scores[label], want)
+					}
+				}
+			}
+			if r.Model != "events" || r.ModelVersion != "v2" || !r.Truncated || r.Latency != 3*time.Millisecond {
+				t.Errorf("metadata not copied: %+v", r)
+			}
+			for _, label := range tt.labels {
+				if !r.Has(label) {
+					t.Errorf("Has(%q) = false", label)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	onnxruntime "github.com/yalue/onnxruntime_go"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultModelConfigPath is where the bot looks for model definitions.
+const DefaultModelConfigPath = "configs/model.yaml"
+
+// ModelDefinition describes a BERT-style ONNX text classifier and how to feed it.
+type ModelDefinition struct {
+	Name              string   `yaml:"-"`
+	Type              string   `yaml:"type"` // onnx (default), lexicon or ensemble
+	Version           string   `yaml:"version"`
+	Path              string   `yaml:"path"`
+	SHA256            string   `yaml:"sha256"` // checked before loading; filled from the registry if empty
+	Size              int64    `yaml:"size"`
+	Vocab             string   `yaml:"vocab"`
+	MaxLength         int      `yaml:"max_length"`
+	InputIDsName      string   `yaml:"input_ids"`
+	AttentionMaskName string   `yaml:"attention_mask"`
+	OutputName        string   `yaml:"output"`
+	Labels            []string `yaml:"labels"`
+	PoolSize          int      `yaml:"pool_size"`  // number of concurrent ONNX sessions
+	BatchSize         int      `yaml:"batch_size"` // texts per model run in AnalyzeBatch
+
+	// Activation turns logits into scores: softmax for single-label models,
+	// sigmoid for multi-label classifiers, which predict every label whose
+	// score reaches Threshold. Sentiment models must use softmax.
+	Activation string  `yaml:"activation"`
+	Threshold  float32 `yaml:"threshold"`
+
+	// Long texts are split into windows of max_length tokens for AnalyzeLong.
+	ChunkOverlap *int   `yaml:"chunk_overlap"` // tokens shared by consecutive windows, 32 if unset
+	MaxChunks    int    `yaml:"max_chunks"`    // windows scored per text, 0 for no limit
+	Aggregation  string `yaml:"aggregation"`   // mean, confidence or max
+
+	// Calibration is loaded from CalibrationPath when that file exists;
+	// cmd/sentimenteval -fit-calibration writes it.
+	CalibrationPath string       `yaml:"calibration"`
+	Calibration     *Calibration `yaml:"-"`
+
+	// Members of an ensemble model; their labels must match.
+	Members    []EnsembleMember  `yaml:"members"`
+	memberDefs []ModelDefinition // resolved Members, for cache fingerprints
+}
+
+// ModelConfig is the content of configs/model.yaml.
+type ModelConfig struct {
+	Default      string          `yaml:"default"`
+	Fallback     string          `yaml:"fallback"` // used when the chosen model can't be loaded
+	Cache        CacheConfig     `yaml:"cache"`
+	Shadow       ShadowConfig    `yaml:"shadow"`
+	Index        IndexConfig     `yaml:"index"`
+	Drift        DriftConfig     `yaml:"drift"`
+	Labeling     LabelingConfig  `yaml:"labeling"`
+	WeakLabeling WeakLabelConfig `yaml:"weak_labeling"`
+
+	Models map[string]ModelDefinition `yaml:"models"`
+
+	// Classifiers are other ONNX text classifiers (event type, relevance,
+	// spam...) served by TextClassifier rather than as sentiment models.
+	Classifiers map[string]ModelDefinition `yaml:"classifiers"`
+
+	RegistryPath string `yaml:"registry"`
+	registry     *ModelRegistry
+}
+
+// LoadModelConfig reads and validates model definitions from a YAML file.
+func LoadModelConfig(path string) (*ModelConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model config: %w", err)
+	}
+
+	var cfg ModelConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse model config %s: %w", path, err)
+	}
+	if len(cfg.Models) == 0 {
+		return nil, fmt.Errorf("model config %s defines no models", path)
+	}
+	if cfg.RegistryPath == "" {
+		cfg.RegistryPath = DefaultRegistryPath
+	}
+	if cfg.registry, err = LoadModelRegistry(cfg.RegistryPath); err != nil {
+		return nil, err
+	}
+
+	for name, def := range cfg.Models {
+		def.Name = name
+		def.applyDefaults()
+		// The registry knows the checksum of the file this version should be
+		// and may know its calibration.
+		if v, found := cfg.registry.Version(name, def.Version); found && v.Path == def.Path {
+			if def.SHA256 == "" {
+				def.SHA256, def.Size = v.SHA256, v.Size
+			}
+			if def.CalibrationPath == "" {
+				def.CalibrationPath = v.Calibration
+			}
+		}
+		cfg.Models[name] = def
+	}
+	// Ensembles take their labels from their members, so they are checked
+	// once every model has its defaults.
+	for name, def := range cfg.Models {
+		if def.Type == ModelTypeEnsemble {
+			if err := cfg.resolveEnsemble(&def); err != nil {
+				return nil, fmt.Errorf("model %q: %w", name, err)
+			}
+		}
+		if err := def.Validate(); err != nil {
+			return nil, fmt.Errorf("model %q: %w", name, err)
+		}
+		if err := def.loadCalibration(); err != nil {
+			return nil, fmt.Errorf("model %q: %w", name, err)
+		}
+		cfg.Models[name] = def
+	}
+	if _, found := cfg.Models[cfg.Default]; !found {
+		return nil, fmt.Errorf("default model %q is not defined in %s", cfg.Default, path)
+	}
+	if _, found := cfg.Models[cfg.Fallback]; cfg.Fallback != "" && !found {
+		return nil, fmt.Errorf("fallback model %q is not defined in %s", cfg.Fallback, path)
+	}
+	for _, name := range cfg.Shadow.Models {
+		if _, found := cfg.Models[name]; !found {
+			return nil, fmt.Errorf("shadow model %q is not defined in %s", name, path)
+		}
+	}
+	for name, def := range cfg.Classifiers {
+		def.Name = name
+		def.applyDefaults()
+		if v, found := cfg.registry.Version(name, def.Version); found && def.SHA256 == "" && v.Path == def.Path {
+			def.SHA256, def.Size = v.SHA256, v.Size
+		}
+		if def.Type != ModelTypeONNX {
+			return nil, fmt.Errorf("classifier %q: only onnx classifiers are supported, got %q", name, def.Type)
+		}
+		if err := def.Validate(); err != nil {
+			return nil, fmt.Errorf("classifier %q: %w", name, err)
+		}
+		cfg.Classifiers[name] = def
+	}
+	return &cfg, nil
+}
+
+// Registry returns the model registry named by the config.
+func (c *ModelConfig) Registry() *ModelRegistry {
+	return c.registry
+}
+
+// Model returns the named definition, or the default one when name is empty.
+func (c *ModelConfig) Model(name string) (ModelDefinition, error) {
+	if name == "" {
+		name = c.Default
+	}
+	def, found := c.Models[name]
+	if !found {
+		return ModelDefinition{}, fmt.Errorf("model %q is not defined", name)
+	}
+	return def, nil
+}
+
+// Classifier returns the named classifier definition.
+func (c *ModelConfig) Classifier(name string) (ModelDefinition, error) {
+	def, found := c.Classifiers[name]
+	if !found {
+		return ModelDefinition{}, fmt.Errorf("classifier %q is not defined", name)
+	}
+	return def, nil
+}
+
+func (d *ModelDefinition) applyDefaults() {
+	if d.Type == "" {
+		d.Type = ModelTypeONNX
+	}
+	if d.Type == ModelTypeLexicon && len(d.Labels) == 0 {
+		d.Labels = []string{"negative", "neutral", "positive"}
+	}
+	if d.MaxLength <= 0 {
+		d.MaxLength = 128
+	}
+	if d.InputIDsName == "" {
+		d.InputIDsName = "input_ids"
+	}
+	if d.AttentionMaskName == "" {
+		d.AttentionMaskName = "attention_mask"
+	}
+	if d.OutputName == "" {
+		d.OutputName = "logits"
+	}
+	if d.PoolSize <= 0 {
+		d.PoolSize = 2
+	}
+	if d.BatchSize <= 0 {
+		d.BatchSize = 16
+	}
+	if d.ChunkOverlap == nil {
+		overlap := 32
+		d.ChunkOverlap = &overlap
+	}
+	if d.Aggregation == "" {
+		d.Aggregation = AggregateMean
+	}
+	if d.Activation == "" {
+		d.Activation = ActivationSoftmax
+	}
+	if d.Threshold <= 0 {
+		d.Threshold = 0.5
+	}
+}
+
+// resolveEnsemble checks that the members of an ensemble exist and share
+// labels, and gives the ensemble those labels.
+func (c *ModelConfig) resolveEnsemble(d *ModelDefinition) error {
+	for _, m := range d.Members {
+		member, found := c.Models[m.Model]
+		if !found {
+			return fmt.Errorf("member %q is not defined", m.Model)
+		}
+		if member.Type == ModelTypeEnsemble {
+			return fmt.Errorf("member %q is itself an ensemble", m.Model)
+		}
+		d.memberDefs = append(d.memberDefs, member)
+		if len(d.Labels) == 0 {
+			d.Labels = member.Labels
+		} else if !slices.Equal(d.Labels, member.Labels) {
+			return fmt.Errorf("member %q has labels %v, ensemble has %v", m.Model, member.Labels, d.Labels)
+		}
+	}
+	return nil
+}
+
+// loadCalibration reads the model's calibration. A missing file leaves the
+// model uncalibrated.
+func (d *ModelDefinition) loadCalibration() error {
+	if d.CalibrationPath == "" {
+		return nil
+	}
+	c, err := LoadCalibration(d.CalibrationPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(c.Labels, d.Labels) {
+		return fmt.Errorf("calibration %s is for labels %v, model has %v", d.CalibrationPath, c.Labels, d.Labels)
+	}
+	d.Calibration = c
+	return nil
+}
+
+// Validate checks the definition for missing fields.
+func (d ModelDefinition) Validate() error {
+	var errs []error
+	switch d.Type {
+	case ModelTypeONNX:
+		if d.Path == "" {
+			errs = append(errs, errors.New("path is required"))
+		}
+		if d.Vocab == "" {
+			errs = append(errs, errors.New("vocab is required"))
+		}
+	case ModelTypeLexicon:
+	case ModelTypeEnsemble:
+		if len(d.Members) == 0 {
+			errs = append(errs, errors.New("an ensemble needs members"))
+		}
+		for _, m := range d.Members {
+			if m.Weight <= 0 {
+				errs = append(errs, fmt.Errorf("member %q needs a positive weight", m.Model))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q", d.Type))
+	}
+	switch d.Activation {
+	case ActivationSoftmax:
+		if len(d.Labels) < 2 {
+			errs = append(errs, fmt.Errorf("at least two labels are required, got %d", len(d.Labels)))
+		}
+	case ActivationSigmoid:
+		// A single sigmoid output is a binary classifier, such as spam.
+		if len(d.Labels) == 0 {
+			errs = append(errs, errors.New("at least one label is required"))
+		}
+		if d.Threshold >= 1 {
+			errs = append(errs, fmt.Errorf("threshold %g must be below 1", d.Threshold))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown activation %q", d.Activation))
+	}
+	switch d.Aggregation {
+	case AggregateMean, AggregateConfidence, AggregateMax:
+	default:
+		errs = append(errs, fmt.Errorf("unknown aggregation %q", d.Aggregation))
+	}
+	if d.ChunkOverlap != nil {
+		if overlap := *d.ChunkOverlap; overlap < 0 || overlap >= d.MaxLength-2 {
+			errs = append(errs, fmt.Errorf("chunk_overlap %d must be between 0 and max_length-3", overlap))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyONNX cross-checks the definition against the input/output metadata
+// stored in the ONNX file, so a mismatched model fails at startup rather
+// than at the first inference. ONNX Runtime must be initialized.
+func (d ModelDefinition) VerifyONNX() error {
+	inputs, outputs, err := onnxruntime.GetInputOutputInfo(d.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read ONNX metadata from %s: %w", d.Path, err)
+	}
+	return d.verifyIO(inputs, outputs)
+}
+
+// verifyIO checks the model's inputs and outputs against the definition.
+func (d ModelDefinition) verifyIO(inputs, outputs []onnxruntime.InputOutputInfo) error {
+	var errs []error
+	for _, name := range []string{d.InputIDsName, d.AttentionMaskName} {
+		info, found := findIOInfo(inputs, name)
+		if !found {
+			errs = append(errs, fmt.Errorf("model has no input %q (inputs: %v)", name, ioNames(inputs)))
+			continue
+		}
+		if info.DataType != onnxruntime.TensorElementDataTypeInt64 {
+			errs = append(errs, fmt.Errorf("input %q is %s, expected int64", name, info.DataType))
+		}
+		if len(info.Dimensions) != 2 {
+			errs = append(errs, fmt.Errorf("input %q has shape %s, expected [batch, sequence]", name, info.Dimensions))
+		} else if seq := info.Dimensions[1]; seq > 0 && seq != int64(d.MaxLength) {
+			errs = append(errs, fmt.Errorf("input %q has fixed sequence length %d, config says %d", name, seq, d.MaxLength))
+		}
+	}
+
+	info, found := findIOInfo(outputs, d.OutputName)
+	switch {
+	case !found:
+		errs = append(errs, fmt.Errorf("model has no output %q (outputs: %v)", d.OutputName, ioNames(outputs)))
+	case info.DataType != onnxruntime.TensorElementDataTypeFloat:
+		errs = append(errs, fmt.Errorf("output %q is %s, expected float32", d.OutputName, info.DataType))
+	case len(info.Dimensions) != 2:
+		errs = append(errs, fmt.Errorf("output %q has shape %s, expected [batch, classes]", d.OutputName, info.Dimensions))
+	case info.Dimensions[1] > 0 && info.Dimensions[1] != int64(len(d.Labels)):
+		errs = append(errs, fmt.Errorf("output %q has %d classes, config lists %d labels", d.OutputName, info.Dimensions[1], len(d.Labels)))
+	}
+	return errors.Join(errs...)
+}
+
+func findIOInfo(infos []onnxruntime.InputOutputInfo, name string) (onnxruntime.InputOutputInfo, bool) {
+	for _, info := range infos {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return onnxruntime.InputOutputInfo{}, false
+}
+
+func ioNames(infos []onnxruntime.InputOutputInfo) []string {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names
+}
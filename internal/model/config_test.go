@@ -0,0 +1,147 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	onnxruntime "github.com/yalue/onnxruntime_go"
+)
+
+func writeModelConfig(t *testing.T, models string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.yaml")
+	cfg := "default: finbert\nregistry: " + filepath.Join(dir, "registry.yaml") + "\nmodels:\n" + models
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChunkOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlap string
+		want    int
+		wantErr string
+	}{
+		{"unset takes the default", "", 32, ""},
+		{"zero is kept", "    chunk_overlap: 0\n", 0, ""},
+		{"explicit", "    chunk_overlap: 16\n", 16, ""},
+		{"negative", "    chunk_overlap: -1\n", 0, "chunk_overlap -1"},
+		{"as long as a window", "    chunk_overlap: 126\n", 0, "chunk_overlap 126"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeModelConfig(t, "  finbert:\n    path: m.onnx\n    vocab: v.txt\n    labels: [negative, neutral, positive]\n"+tt.overlap)
+			cfg, err := LoadModelConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := *cfg.Models["finbert"].ChunkOverlap; got != tt.want {
+				t.Errorf("chunk overlap = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadModelConfigDefaults(t *testing.T) {
+	path := writeModelConfig(t, "  finbert:\n    path: m.onnx\n    vocab: v.txt\n    labels: [negative, neutral, positive]\n  lexicon:\n    type: lexicon\n")
+	cfg, err := LoadModelConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := cfg.Models["finbert"]
+	if d.Type != ModelTypeONNX || d.MaxLength != 128 || d.PoolSize != 2 || d.BatchSize != 16 || *d.ChunkOverlap != 32 {
+		t.Errorf("type %q max_length %d pool_size %d batch_size %d chunk_overlap %d, want onnx 128 2 16 32",
+			d.Type, d.MaxLength, d.PoolSize, d.BatchSize, *d.ChunkOverlap)
+	}
+	if d.InputIDsName != "input_ids" || d.AttentionMaskName != "attention_mask" || d.OutputName != "logits" {
+		t.Errorf("io names %q %q %q, want input_ids attention_mask logits", d.InputIDsName, d.AttentionMaskName, d.OutputName)
+	}
+	if d.Aggregation != AggregateMean || d.Activation != ActivationSoftmax || d.Threshold != 0.5 {
+		t.Errorf("aggregation %q activation %q threshold %g, want %s %s 0.5", d.Aggregation, d.Activation, d.Threshold, AggregateMean, ActivationSoftmax)
+	}
+
+	if got := cfg.Models["lexicon"].Labels; !slices.Equal(got, []string{"negative", "neutral", "positive"}) {
+		t.Errorf("lexicon labels = %v", got)
+	}
+}
+
+func TestLoadModelConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		models  string
+		wantErr string
+	}{
+		{"no path", "  finbert:\n    vocab: v.txt\n    labels: [negative, positive]\n", "path is required"},
+		{"no vocab", "  finbert:\n    path: m.onnx\n    labels: [negative, positive]\n", "vocab is required"},
+		{"one softmax label", "  finbert:\n    path: m.onnx\n    vocab: v.txt\n    labels: [positive]\n", "at least two labels"},
+		{"unknown type", "  finbert:\n    type: torch\n    labels: [negative, positive]\n", `unknown type "torch"`},
+		{"unknown activation", "  finbert:\n    type: lexicon\n    activation: relu\n", `unknown activation "relu"`},
+		{"unknown aggregation", "  finbert:\n    type: lexicon\n    aggregation: median\n", `unknown aggregation "median"`},
+		{"sigmoid threshold", "  finbert:\n    type: lexicon\n    activation: sigmoid\n    threshold: 1\n", "threshold 1 must be below 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadModelConfig(writeModelConfig(t, tt.models))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIO(t *testing.T) {
+	def := ModelDefinition{Labels: []string{"negative", "neutral", "positive"}}
+	def.applyDefaults()
+	input := func(name string, dims ...int64) onnxruntime.InputOutputInfo {
+		return onnxruntime.InputOutputInfo{Name: name, Dimensions: dims, DataType: onnxruntime.TensorElementDataTypeInt64}
+	}
+	logits := func(classes int64) onnxruntime.InputOutputInfo {
+		return onnxruntime.InputOutputInfo{Name: "logits", Dimensions: onnxruntime.Shape{-1, classes}, DataType: onnxruntime.TensorElementDataTypeFloat}
+	}
+	inputs := []onnxruntime.InputOutputInfo{input("input_ids", -1, -1), input("attention_mask", -1, -1)}
+	outputs := []onnxruntime.InputOutputInfo{logits(3)}
+
+	tests := []struct {
+		name    string
+		inputs  []onnxruntime.InputOutputInfo
+		outputs []onnxruntime.InputOutputInfo
+		wantErr string
+	}{
+		{"dynamic shapes", inputs, outputs, ""},
+		{"fixed max length", []onnxruntime.InputOutputInfo{input("input_ids", 1, 128), input("attention_mask", 1, 128)}, outputs, ""},
+		{"missing input", inputs[:1], outputs, `no input "attention_mask"`},
+		{"float input", []onnxruntime.InputOutputInfo{inputs[0], {Name: "attention_mask", Dimensions: onnxruntime.Shape{-1, -1}, DataType: onnxruntime.TensorElementDataTypeFloat}}, outputs, "expected int64"},
+		{"other sequence length", []onnxruntime.InputOutputInfo{input("input_ids", -1, 512), inputs[1]}, outputs, "fixed sequence length 512, config says 128"},
+		{"rank 3 input", []onnxruntime.InputOutputInfo{input("input_ids", -1, -1, 1), inputs[1]}, outputs, "expected [batch, sequence]"},
+		{"missing output", inputs, []onnxruntime.InputOutputInfo{{Name: "probs"}}, `no output "logits"`},
+		{"int output", inputs, []onnxruntime.InputOutputInfo{input("logits", -1, 3)}, "expected float32"},
+		{"two classes for three labels", inputs, []onnxruntime.InputOutputInfo{logits(2)}, "2 classes, config lists 3 labels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := def.verifyIO(tt.inputs, tt.outputs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	driftPSI = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiment_drift_psi",
+			Help: "Population stability index of recent predictions against the reference window, by feature (label, confidence, score)",
+		},
+		[]string{"model", "feature"},
+	)
+	driftKL = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiment_drift_kl",
+			Help: "KL divergence of recent predictions from the reference window, by feature",
+		},
+		[]string{"model", "feature"},
+	)
+	driftAlerting = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiment_drift_alert",
+			Help: "1 while a feature's drift exceeds its threshold, 0 otherwise",
+		},
+		[]string{"model", "feature"},
+	)
+	driftAlerts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_drift_alerts_total",
+			Help: "Drift alerts raised, by feature",
+		},
+		[]string{"model", "feature"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(driftPSI, driftKL, driftAlerting, driftAlerts)
+}
+
+// Drift features: the predicted label, and histograms of the confidence and
+// of the score.
+const (
+	DriftFeatureLabel      = "label"
+	DriftFeatureConfidence = "confidence"
+	DriftFeatureScore      = "score"
+)
+
+// driftEpsilon stands in for empty bins, whose log ratio would be infinite.
+const driftEpsilon = 1e-4
+
+// DriftConfig enables drift detection in the drift section of
+// configs/model.yaml.
+type DriftConfig struct {
+	Window       int     `yaml:"window"`        // recent predictions compared with the reference, 0 disables detection
+	Reference    int     `yaml:"reference"`     // predictions in the reference window, Window if 0
+	ReferenceDir string  `yaml:"reference_dir"` // saves the reference per model version so restarts keep it
+	Bins         int     `yaml:"bins"`          // confidence and score histogram bins
+	CheckEvery   int     `yaml:"check_every"`   // predictions between checks
+	PSIThreshold float64 `yaml:"psi_threshold"` // alert when a feature's PSI exceeds this
+	KLThreshold  float64 `yaml:"kl_threshold"`  // or its KL divergence exceeds this
+	Log          string  `yaml:"log"`           // JSON lines file for alerts, none if empty
+}
+
+// Enabled reports whether drift detection is configured.
+func (c DriftConfig) Enabled() bool {
+	return c.Window > 0
+}
+
+func (c *DriftConfig) applyDefaults() {
+	if c.Reference <= 0 {
+		c.Reference = c.Window
+	}
+	if c.Bins <= 0 {
+		c.Bins = 10
+	}
+	if c.CheckEvery <= 0 {
+		c.CheckEvery = c.Window / 10
+		if c.CheckEvery == 0 {
+			c.CheckEvery = 1
+		}
+	}
+	if c.PSIThreshold <= 0 {
+		c.PSIThreshold = 0.2
+	}
+	if c.KLThreshold <= 0 {
+		c.KLThreshold = 0.1
+	}
+}
+
+// DriftReference is the distribution of a model version's predictions that
+// later predictions are compared with.
+type DriftReference struct {
+	Model       string    `json:"model"`
+	Version     string    `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	Predictions int       `json:"predictions"`
+	Labels      []string  `json:"labels"`
+	LabelCounts []int     `json:"label_counts"`
+	Confidence  []int     `json:"confidence"` // histogram over [0, 1]
+	Score       []int     `json:"score"`      // histogram over [-1, 1]
+}
+
+// DriftAlert is raised when a feature's drift first exceeds a threshold. It
+// is not raised again until the feature drops back under both thresholds.
+type DriftAlert struct {
+	Time      time.Time `json:"time"`
+	Model     string    `json:"model"`
+	Version   string    `json:"version"`
+	Feature   string    `json:"feature"`
+	PSI       float64   `json:"psi"`
+	KL        float64   `json:"kl"`
+	Reference []float64 `json:"reference"` // bin proportions
+	Recent    []float64 `json:"recent"`
+}
+
+// FeatureDrift is the latest comparison of one feature.
+type FeatureDrift struct {
+	Feature  string  `json:"feature"`
+	PSI      float64 `json:"psi"`
+	KL       float64 `json:"kl"`
+	Alerting bool    `json:"alerting"`
+}
+
+// DriftStatus is a snapshot of a DriftDetector.
+type DriftStatus struct {
+	Model         string         `json:"model"`
+	Version       string         `json:"version"`
+	Reference     int            `json:"reference"` // predictions collected in the reference so far
+	ReferenceFull bool           `json:"reference_full"`
+	Recent        int            `json:"recent"`
+	CheckedAt     time.Time      `json:"checked_at"`
+	Features      []FeatureDrift `json:"features"`
+}
+
+// DriftDetector compares the distribution of a model's recent predictions
+// with a reference window using the population stability index and the KL
+// divergence of recent from reference. The reference is the first
+// Reference predictions of the model version, saved to ReferenceDir when it
+// is set so that a restart, possibly onto already drifted traffic, doesn't
+// re-baseline. Recent is a sliding window of the last Window predictions.
+// Scores are exported as Prometheus gauges; alerts are printed, logged and
+// counted.
+type DriftDetector struct {
+	cfg DriftConfig
+	def ModelDefinition
+
+	mu        sync.Mutex
+	ref       DriftReference
+	recent    []driftObservation // ring buffer
+	next      int
+	counts    driftCounts // of recent
+	sinceLast int
+	alerting  map[string]bool
+	status    DriftStatus
+	log       *jsonLog
+
+	shared string // key in driftDetectors, empty if not shared
+	refs   int    // guarded by driftDetectorsMu
+}
+
+type driftObservation struct {
+	label, confidence, score int
+}
+
+type driftCounts struct {
+	labels, confidence, score []int
+}
+
+func newDriftCounts(labels, bins int) driftCounts {
+	return driftCounts{labels: make([]int, labels), confidence: make([]int, bins), score: make([]int, bins)}
+}
+
+func (c driftCounts) add(o driftObservation, delta int) {
+	if o.label >= 0 {
+		c.labels[o.label] += delta
+	}
+	c.confidence[o.confidence] += delta
+	c.score[o.score] += delta
+}
+
+// NewDriftDetector creates the detector for def's predictions, loading its
+// saved reference if there is one.
+func NewDriftDetector(def ModelDefinition, cfg DriftConfig) (*DriftDetector, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("drift detection needs a window")
+	}
+	cfg.applyDefaults()
+	d := &DriftDetector{
+		cfg:      cfg,
+		def:      def,
+		counts:   newDriftCounts(len(def.Labels), cfg.Bins),
+		alerting: make(map[string]bool),
+	}
+	d.resetReferenceLocked()
+
+	if path := d.referencePath(); path != "" {
+		raw, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("failed to read drift reference: %w", err)
+		default:
+			var ref DriftReference
+			if err := json.Unmarshal(raw, &ref); err != nil {
+				return nil, fmt.Errorf("failed to parse drift reference %s: %w", path, err)
+			}
+			// A reference from other labels or bins can't be compared; it
+			// is rebuilt.
+			if len(ref.LabelCounts) == len(def.Labels) && len(ref.Confidence) == cfg.Bins && len(ref.Score) == cfg.Bins {
+				d.ref = ref
+			}
+		}
+	}
+
+	if cfg.Log != "" {
+		log, err := openJSONLog(cfg.Log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open drift log: %w", err)
+		}
+		d.log = log
+	}
+	return d, nil
+}
+
+var (
+	driftDetectorsMu sync.Mutex
+	driftDetectors   = make(map[string]*DriftDetector)
+)
+
+// openDriftDetector returns the detector for def's version. Analyzers of the
+// same version share one detector, so that swapping onto a version that is
+// already loaded continues its windows instead of a second detector saving
+// the same reference.
+func openDriftDetector(def ModelDefinition, cfg DriftConfig) (*DriftDetector, error) {
+	key := def.Name + "\x00" + def.Version
+	driftDetectorsMu.Lock()
+	defer driftDetectorsMu.Unlock()
+	if d, found := driftDetectors[key]; found {
+		d.refs++
+		return d, nil
+	}
+	d, err := NewDriftDetector(def, cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.shared, d.refs = key, 1
+	driftDetectors[key] = d
+	return d, nil
+}
+
+// Observe records one prediction. Until the reference is full, predictions
+// go to the reference; after that, to the recent window, and drift is
+// checked every CheckEvery predictions once the window is full.
+func (d *DriftDetector) Observe(res SentimentResult) {
+	o := driftObservation{
+		label:      -1,
+		confidence: driftBin(float64(res.Confidence), 0, 1, d.cfg.Bins),
+		score:      driftBin(float64(res.Score), -1, 1, d.cfg.Bins),
+	}
+	for i, label := range d.def.Labels {
+		if label == res.Label {
+			o.label = i
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ref.Predictions < d.cfg.Reference {
+		d.ref.Predictions++
+		if o.label >= 0 {
+			d.ref.LabelCounts[o.label]++
+		}
+		d.ref.Confidence[o.confidence]++
+		d.ref.Score[o.score]++
+		if d.ref.Predictions == d.cfg.Reference {
+			d.saveReferenceLocked()
+		}
+		return
+	}
+
+	if len(d.recent) < d.cfg.Window {
+		d.recent = append(d.recent, o)
+	} else {
+		d.counts.add(d.recent[d.next], -1)
+		d.recent[d.next] = o
+		d.next = (d.next + 1) % d.cfg.Window
+	}
+	d.counts.add(o, 1)
+
+	d.sinceLast++
+	if len(d.recent) == d.cfg.Window && d.sinceLast >= d.cfg.CheckEvery {
+		d.sinceLast = 0
+		d.checkLocked()
+	}
+}
+
+// Status returns the latest comparison.
+func (d *DriftDetector) Status() DriftStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.status
+	s.Model, s.Version = d.def.Name, d.def.Version
+	s.Reference = d.ref.Predictions
+	s.ReferenceFull = d.ref.Predictions >= d.cfg.Reference
+	s.Recent = len(d.recent)
+	s.Features = append([]FeatureDrift(nil), d.status.Features...)
+	return s
+}
+
+// ResetReference discards the reference and the recent window so that the
+// next predictions form a new reference, for example after a shift that was
+// investigated and accepted.
+func (d *DriftDetector) ResetReference() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resetReferenceLocked()
+	d.recent, d.next, d.sinceLast = nil, 0, 0
+	d.counts = newDriftCounts(len(d.def.Labels), d.cfg.Bins)
+	d.status = DriftStatus{}
+	for feature := range d.alerting {
+		d.alerting[feature] = false
+		driftAlerting.WithLabelValues(d.def.Name, feature).Set(0)
+	}
+	if path := d.referencePath(); path != "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove drift reference: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the alert log. A shared detector is closed by its last user.
+func (d *DriftDetector) Close() error {
+	if d.shared != "" {
+		driftDetectorsMu.Lock()
+		d.refs--
+		last := d.refs == 0
+		if last {
+			delete(driftDetectors, d.shared)
+		}
+		driftDetectorsMu.Unlock()
+		if !last {
+			return nil
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.log == nil {
+		return nil
+	}
+	err := d.log.Close()
+	d.log = nil
+	return err
+}
+
+func (d *DriftDetector) resetReferenceLocked() {
+	d.ref = DriftReference{
+		Model:       d.def.Name,
+		Version:     d.def.Version,
+		CreatedAt:   time.Now().UTC(),
+		Labels:      d.def.Labels,
+		LabelCounts: make([]int, len(d.def.Labels)),
+		Confidence:  make([]int, d.cfg.Bins),
+		Score:       make([]int, d.cfg.Bins),
+	}
+}
+
+// referencePath is the reference file of the model version, or "" when
+// references aren't saved.
+func (d *DriftDetector) referencePath() string {
+	if d.cfg.ReferenceDir == "" {
+		return ""
+	}
+	version := d.def.Version
+	if version == "" {
+		version = "unversioned"
+	}
+	return filepath.Join(d.cfg.ReferenceDir, d.def.Name, version+".json")
+}
+
+// saveReferenceLocked writes the completed reference. Failing to save only
+// means it is rebuilt after a restart.
+func (d *DriftDetector) saveReferenceLocked() {
+	path := d.referencePath()
+	if path == "" {
+		return
+	}
+	raw, err := json.MarshalIndent(d.ref, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, raw, 0o644)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error saving drift reference:", err)
+	}
+}
+
+func (d *DriftDetector) checkLocked() {
+	now := time.Now().UTC()
+	features := []struct {
+		name        string
+		ref, recent []int
+	}{
+		{DriftFeatureLabel, d.ref.LabelCounts, d.counts.labels},
+		{DriftFeatureConfidence, d.ref.Confidence, d.counts.confidence},
+		{DriftFeatureScore, d.ref.Score, d.counts.score},
+	}
+
+	d.status.CheckedAt = now
+	d.status.Features = d.status.Features[:0]
+	for _, f := range features {
+		ref, recent := driftProportions(f.ref), driftProportions(f.recent)
+		psi, kl := PSI(ref, recent), KLDivergence(recent, ref)
+		exceeded := psi > d.cfg.PSIThreshold || kl > d.cfg.KLThreshold
+
+		driftPSI.WithLabelValues(d.def.Name, f.name).Set(psi)
+		driftKL.WithLabelValues(d.def.Name, f.name).Set(kl)
+		if exceeded {
+			driftAlerting.WithLabelValues(d.def.Name, f.name).Set(1)
+		} else {
+			driftAlerting.WithLabelValues(d.def.Name, f.name).Set(0)
+		}
+		d.status.Features = append(d.status.Features, FeatureDrift{Feature: f.name, PSI: psi, KL: kl, Alerting: exceeded})
+
+		if exceeded && !d.alerting[f.name] {
+			d.alert(DriftAlert{
+				Time: now, Model: d.def.Name, Version: d.def.Version, Feature: f.name,
+				PSI: psi, KL: kl, Reference: ref, Recent: recent,
+			})
+		}
+		d.alerting[f.name] = exceeded
+	}
+}
+
+func (d *DriftDetector) alert(a DriftAlert) {
+	driftAlerts.WithLabelValues(a.Model, a.Feature).Inc()
+	fmt.Printf("⚠️ Sentiment drift on %s %s: %s PSI %.3f, KL %.3f over the last %d predictions\n",
+		a.Model, a.Version, a.Feature, a.PSI, a.KL, len(d.recent))
+	if d.log == nil {
+		return
+	}
+	if err := d.log.Write(a); err != nil {
+		fmt.Println("Error writing drift log:", err)
+	}
+}
+
+// PSI returns the population stability index of actual against expected,
+// both bin proportions. Below 0.1 is usually read as stable, 0.1 to 0.2 as
+// a moderate shift and above 0.2 as significant.
+func PSI(expected, actual []float64) float64 {
+	var psi float64
+	for i := range expected {
+		e, a := math.Max(expected[i], driftEpsilon), math.Max(actual[i], driftEpsilon)
+		psi += (a - e) * math.Log(a/e)
+	}
+	return psi
+}
+
+// KLDivergence returns KL(p || q) in nats for bin proportions p and q.
+func KLDivergence(p, q []float64) float64 {
+	var kl float64
+	for i := range p {
+		if p[i] <= 0 {
+			continue
+		}
+		kl += p[i] * math.Log(p[i]/math.Max(q[i], driftEpsilon))
+	}
+	return kl
+}
+
+func driftProportions(counts []int) []float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	p := make([]float64, len(counts))
+	if total == 0 {
+		return p
+	}
+	for i, c := range counts {
+		p[i] = float64(c) / float64(total)
+	}
+	return p
+}
+
+// driftBin returns the bin of v among n equal bins over [min, max].
+func driftBin(v, min, max float64, n int) int {
+	i := int((v - min) / (max - min) * float64(n))
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPSIAndKLDivergence(t *testing.T) {
+	tests := []struct {
+		name             string
+		expected, actual []float64
+		psi, kl          float64 // kl is KL(actual || expected), as checked by the detector
+	}{
+		{"identical", []float64{0.2, 0.3, 0.5}, []float64{0.2, 0.3, 0.5}, 0, 0},
+		{"shift", []float64{0.5, 0.5}, []float64{0.9, 0.1}, 0.8788898309344878, 0.3680642071684971},
+		{"reverse shift", []float64{0.9, 0.1}, []float64{0.5, 0.5}, 0.8788898309344878, 0.5108256237659907},
+		{"moderate", []float64{0.25, 0.25, 0.25, 0.25}, []float64{0.1, 0.2, 0.3, 0.4}, 0.22821740957339182, 0.10644013528622318},
+		// Empty bins stand in as driftEpsilon instead of making the log infinite.
+		{"empty recent bin", []float64{0.5, 0.5}, []float64{1, 0}, 4.60431846666895, 0.6931471805599453},
+		{"empty reference bin", []float64{1, 0}, []float64{0.5, 0.5}, 4.60431846666895, 3.9120230054281464},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PSI(tt.expected, tt.actual); math.Abs(got-tt.psi) > 1e-9 {
+				t.Errorf("PSI = %v, want %v", got, tt.psi)
+			}
+			if got := KLDivergence(tt.actual, tt.expected); math.Abs(got-tt.kl) > 1e-9 {
+				t.Errorf("KL = %v, want %v", got, tt.kl)
+			}
+		})
+	}
+}
+
+func TestDriftBin(t *testing.T) {
+	tests := []struct {
+		v, min, max float64
+		want        int
+	}{
+		{0, 0, 1, 0},
+		{0.05, 0, 1, 0},
+		{0.1, 0, 1, 1},
+		{0.99, 0, 1, 9},
+		{1, 0, 1, 9}, // the top edge belongs to the last bin
+		{-1, -1, 1, 0},
+		{0, -1, 1, 5},
+		{-2, -1, 1, 0}, // out of range values are clamped
+		{2, -1, 1, 9},
+	}
+	for _, tt := range tests {
+		if got := driftBin(tt.v, tt.min, tt.max, 10); got != tt.want {
+			t.Errorf("driftBin(%v, %v, %v) = %d, want %d", tt.v, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestDriftDetectorAlertsOnShift(t *testing.T) {
+	def := ModelDefinition{Name: "finbert", Version: "v1", Labels: []string{"negative", "neutral", "positive"}}
+	d, err := NewDriftDetector(def, DriftConfig{Window: 20, CheckEvery: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	positive := SentimentResult{Label: "positive", Confidence: 0.9, Score: 0.85}
+	negative := SentimentResult{Label: "negative", Confidence: 0.9, Score: -0.85}
+
+	// Reference: mostly positive. Then the same mix, which doesn't alert.
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 20; i++ {
+			if i%4 == 0 {
+				d.Observe(negative)
+			} else {
+				d.Observe(positive)
+			}
+		}
+	}
+	for _, f := range d.Status().Features {
+		if f.Alerting || f.PSI > 1e-9 {
+			t.Errorf("%s drifted on the reference mix: %+v", f.Feature, f)
+		}
+	}
+
+	// Then mostly negative.
+	for i := 0; i < 20; i++ {
+		if i%4 == 0 {
+			d.Observe(positive)
+		} else {
+			d.Observe(negative)
+		}
+	}
+	status := d.Status()
+	if !status.ReferenceFull || status.Recent != 20 {
+		t.Fatalf("status %+v", status)
+	}
+	for _, f := range status.Features {
+		if f.Feature == DriftFeatureConfidence {
+			if f.Alerting {
+				t.Errorf("confidence unchanged but alerting: %+v", f)
+			}
+			continue
+		}
+		if !f.Alerting {
+			t.Errorf("%s not alerting after the shift: %+v", f.Feature, f)
+		}
+	}
+}
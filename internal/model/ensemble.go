@@ -0,0 +1,138 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EnsembleMember is one model of an ensemble and its weight.
+type EnsembleMember struct {
+	Model  string  `yaml:"model"`
+	Weight float64 `yaml:"weight"`
+}
+
+// EnsembleAnalyzer scores each text with all member models concurrently and
+// returns the weighted average of their probabilities. Members that fail on
+// a text are left out and the remaining weights renormalized, so the
+// ensemble only fails when every member does.
+type EnsembleAnalyzer struct {
+	def     ModelDefinition
+	members []SentimentAnalyzer
+	weights []float64
+}
+
+// newEnsembleAnalyzer opens every member of def from cfg.
+func newEnsembleAnalyzer(cfg *ModelConfig, def ModelDefinition) (*EnsembleAnalyzer, error) {
+	e := &EnsembleAnalyzer{def: def}
+	for _, m := range def.Members {
+		member, err := cfg.Model(m.Model)
+		if err != nil {
+			e.Close()
+			return nil, err
+		}
+		analyzer, err := NewSentimentAnalyzer(member)
+		if err != nil {
+			e.Close()
+			return nil, fmt.Errorf("ensemble %q member %q: %w", def.Name, m.Model, err)
+		}
+		e.members = append(e.members, analyzer)
+		e.weights = append(e.weights, m.Weight)
+	}
+	return e, nil
+}
+
+// Analyze scores text with every member.
+func (e *EnsembleAnalyzer) Analyze(text string) (SentimentResult, error) {
+	return e.combineOne(func(a SentimentAnalyzer) (SentimentResult, error) { return a.Analyze(text) })
+}
+
+// AnalyzeLong scores a long text with every member's AnalyzeLong.
+func (e *EnsembleAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return e.combineOne(func(a SentimentAnalyzer) (SentimentResult, error) { return a.AnalyzeLong(text) })
+}
+
+// AnalyzeBatch runs each member's AnalyzeBatch concurrently and combines the
+// results text by text.
+func (e *EnsembleAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	start := time.Now()
+	perMember := make([][]BatchResult, len(e.members))
+	var wg sync.WaitGroup
+	for i, m := range e.members {
+		wg.Add(1)
+		go func(i int, m SentimentAnalyzer) {
+			defer wg.Done()
+			perMember[i] = m.AnalyzeBatch(texts)
+		}(i, m)
+	}
+	wg.Wait()
+
+	results := make([]BatchResult, len(texts))
+	for t := range texts {
+		memberResults := make([]BatchResult, len(e.members))
+		for i := range e.members {
+			memberResults[i] = perMember[i][t]
+		}
+		results[t].SentimentResult, results[t].Err = e.combine(memberResults, time.Since(start))
+	}
+	return results
+}
+
+// Close closes every member.
+func (e *EnsembleAnalyzer) Close() error {
+	var errs []error
+	for _, m := range e.members {
+		errs = append(errs, m.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (e *EnsembleAnalyzer) combineOne(analyze func(SentimentAnalyzer) (SentimentResult, error)) (SentimentResult, error) {
+	start := time.Now()
+	results := make([]BatchResult, len(e.members))
+	var wg sync.WaitGroup
+	for i, m := range e.members {
+		wg.Add(1)
+		go func(i int, m SentimentAnalyzer) {
+			defer wg.Done()
+			results[i].SentimentResult, results[i].Err = analyze(m)
+		}(i, m)
+	}
+	wg.Wait()
+	return e.combine(results, time.Since(start))
+}
+
+// combine averages the members' probabilities with their weights. Members'
+// results are kept in Members of the combined result.
+func (e *EnsembleAnalyzer) combine(results []BatchResult, latency time.Duration) (SentimentResult, error) {
+	probs := make([]float32, len(e.def.Labels))
+	var total float64
+	var members []SentimentResult
+	var errs []error
+	truncated := false
+	for i, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+			continue
+		}
+		for c, label := range e.def.Labels {
+			probs[c] += float32(e.weights[i]) * r.Probabilities[label]
+		}
+		total += e.weights[i]
+		truncated = truncated || r.Truncated
+		members = append(members, r.SentimentResult)
+	}
+	if len(members) == 0 {
+		return SentimentResult{}, fmt.Errorf("every member of ensemble %q failed: %w", e.def.Name, errors.Join(errs...))
+	}
+	if total > 0 {
+		for c := range probs {
+			probs[c] /= float32(total)
+		}
+	}
+
+	res := newSentimentResult(e.def, probs, truncated, latency)
+	res.Members = members
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// fixedAnalyzer returns the same probabilities, or error, for every text.
+type fixedAnalyzer struct {
+	probs map[string]float32
+	err   error
+}
+
+func (a fixedAnalyzer) Analyze(text string) (SentimentResult, error) {
+	if a.err != nil {
+		return SentimentResult{}, a.err
+	}
+	return SentimentResult{Probabilities: a.probs}, nil
+}
+
+func (a fixedAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return a.Analyze(text)
+}
+
+func (a fixedAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = a.Analyze(text)
+	}
+	return results
+}
+
+func (a fixedAnalyzer) Close() error { return nil }
+
+func TestEnsembleWeighting(t *testing.T) {
+	bullish := fixedAnalyzer{probs: map[string]float32{"negative": 0, "neutral": 0.2, "positive": 0.8}}
+	bearish := fixedAnalyzer{probs: map[string]float32{"negative": 0.6, "neutral": 0.4, "positive": 0}}
+	failing := fixedAnalyzer{err: errors.New("model unavailable")}
+
+	tests := []struct {
+		name    string
+		members []SentimentAnalyzer
+		weights []float64
+		want    map[string]float32
+		label   string
+		used    int // member results kept in the combined result
+		wantErr bool
+	}{
+		{
+			name:    "equal weights",
+			members: []SentimentAnalyzer{bullish, bearish},
+			weights: []float64{1, 1},
+			want:    map[string]float32{"negative": 0.3, "neutral": 0.3, "positive": 0.4},
+			label:   "positive",
+			used:    2,
+		},
+		{
+			name:    "weights need not sum to one",
+			members: []SentimentAnalyzer{bullish, bearish},
+			weights: []float64{1, 3},
+			want:    map[string]float32{"negative": 0.45, "neutral": 0.35, "positive": 0.2},
+			label:   "negative",
+			used:    2,
+		},
+		{
+			name:    "failed member left out and weights renormalized",
+			members: []SentimentAnalyzer{failing, bearish},
+			weights: []float64{3, 1},
+			want:    map[string]float32{"negative": 0.6, "neutral": 0.4, "positive": 0},
+			label:   "negative",
+			used:    1,
+		},
+		{
+			name:    "every member failed",
+			members: []SentimentAnalyzer{failing, failing},
+			weights: []float64{1, 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &EnsembleAnalyzer{
+				def:     ModelDefinition{Name: "ensemble", Labels: []string{"negative", "neutral", "positive"}},
+				members: tt.members,
+				weights: tt.weights,
+			}
+			check := func(res SentimentResult, err error) {
+				t.Helper()
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("expected an error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				for label, p := range tt.want {
+					if math.Abs(float64(res.Probabilities[label]-p)) > 1e-6 {
+						t.Errorf("p(%s) = %v, want %v", label, res.Probabilities[label], p)
+					}
+				}
+				if res.Label != tt.label {
+					t.Errorf("label = %s, want %s", res.Label, tt.label)
+				}
+				if len(res.Members) != tt.used {
+					t.Errorf("%d member results, want %d", len(res.Members), tt.used)
+				}
+			}
+			check(e.Analyze("Infosys beats estimates"))
+			batch := e.AnalyzeBatch([]string{"Infosys beats estimates", "TCS misses estimates"})
+			for _, r := range batch {
+				check(r.SentimentResult, r.Err)
+			}
+		})
+	}
+}
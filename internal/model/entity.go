@@ -0,0 +1,406 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEntitiesPath is where company aliases are configured.
+const DefaultEntitiesPath = "configs/entities.yaml"
+
+// Entity is a company and the names it appears under in news text.
+type Entity struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"` // matched case-insensitively on word boundaries; Name is always included
+}
+
+// EntitySentiment is the sentiment of the parts of a text about one entity.
+type EntitySentiment struct {
+	Entity    string          `json:"entity"`
+	Mentions  int             `json:"mentions"` // sentences or clauses attributed to the entity
+	Text      string          `json:"text"`     // the attributed parts that were scored
+	Shared    bool            `json:"shared"`   // some parts also mention other entities and couldn't be separated
+	Sentiment SentimentResult `json:"sentiment"`
+}
+
+// LoadEntities reads company aliases from a YAML file mapping each company
+// to its aliases:
+//
+//	Infosys: [INFY, Infosys Ltd]
+func LoadEntities(path string) ([]Entity, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entities: %w", err)
+	}
+	var byName map[string][]string
+	if err := yaml.Unmarshal(raw, &byName); err != nil {
+		return nil, fmt.Errorf("failed to parse entities %s: %w", path, err)
+	}
+
+	entities := make([]Entity, 0, len(byName))
+	for name, aliases := range byName {
+		entities = append(entities, Entity{Name: name, Aliases: aliases})
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Name < entities[j].Name })
+	return entities, nil
+}
+
+// FindEntity returns the entity whose name or an alias equals name, ignoring
+// case, or a new entity known only by name.
+func FindEntity(entities []Entity, name string) Entity {
+	for _, e := range entities {
+		for _, alias := range e.names() {
+			if strings.EqualFold(alias, name) {
+				return e
+			}
+		}
+	}
+	return Entity{Name: name}
+}
+
+func (e Entity) names() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// Clause markers. Strong markers separate statements about different
+// subjects ("Infosys beats estimates while Wipro misses"). Weak ones also
+// join lists ("profit and revenue"), so a clause is only split at them when
+// each side names an entity of its own.
+var (
+	strongClauseMarker = regexp.MustCompile(`(?i)\s*[,;]?\s*\b(?:while|whereas|but|however|although|though|even as|meanwhile)\b[,]?\s*|\s*;\s*`)
+	weakClauseMarker   = regexp.MustCompile(`(?i)\s*,?\s+\band\b\s+|\s*,\s*`)
+)
+
+// referringStart matches sentences that continue about the previous
+// sentence's company without naming it.
+var referringStart = regexp.MustCompile(`(?i)^(?:it|its|the (?:company|firm|group|bank|lender|insurer|stock|scrip)|shares|the shares)\b`)
+
+// abbreviations that end with a period without ending a sentence.
+var sentenceAbbreviations = toSet(`ltd inc co corp rs no vs mr mrs ms dr st jr sr bros pvt approx est`)
+
+// AnalyzeEntities splits text into sentences and, where several entities
+// share a sentence, into clauses, attributes each part to the entities it
+// names, and scores each mentioned entity's parts with analyzer. Parts that
+// name no entity are attributed to the previous part's entities when they
+// continue a clause or start with "it", "the company" and the like.
+// Entities the text doesn't mention are left out of the result.
+func AnalyzeEntities(analyzer SentimentAnalyzer, text string, entities []Entity) ([]EntitySentiment, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyText
+	}
+
+	type attribution struct {
+		parts  []string
+		shared bool
+	}
+	byEntity := make(map[int]*attribution)
+	var previous []int
+	for _, sentence := range splitSentences(text) {
+		sentenceStart := true
+		for _, clause := range splitClauses(sentence, entities) {
+			mentioned := mentionedEntities(clause, entities)
+			if len(mentioned) == 0 {
+				if !sentenceStart || referringStart.MatchString(clause) {
+					mentioned = previous
+				}
+			}
+			sentenceStart = false
+			for _, idx := range mentioned {
+				a := byEntity[idx]
+				if a == nil {
+					a = &attribution{}
+					byEntity[idx] = a
+				}
+				a.parts = append(a.parts, clause)
+				a.shared = a.shared || len(mentioned) > 1
+			}
+			if len(mentioned) > 0 {
+				previous = mentioned
+			}
+		}
+	}
+	if len(byEntity) == 0 {
+		return nil, nil
+	}
+
+	order := make([]int, 0, len(byEntity))
+	for idx := range byEntity {
+		order = append(order, idx)
+	}
+	sort.Ints(order)
+	texts := make([]string, len(order))
+	for i, idx := range order {
+		texts[i] = joinParts(byEntity[idx].parts)
+	}
+
+	results := analyzer.AnalyzeBatch(texts)
+	out := make([]EntitySentiment, 0, len(order))
+	var errs []error
+	for i, idx := range order {
+		if results[i].Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entities[idx].Name, results[i].Err))
+			continue
+		}
+		out = append(out, EntitySentiment{
+			Entity:    entities[idx].Name,
+			Mentions:  len(byEntity[idx].parts),
+			Text:      texts[i],
+			Shared:    byEntity[idx].shared,
+			Sentiment: results[i].SentimentResult,
+		})
+	}
+	return out, errors.Join(errs...)
+}
+
+// AnalyzeTargeted runs AnalyzeEntities with the shared analyzer.
+func AnalyzeTargeted(text string, entities []Entity) ([]EntitySentiment, error) {
+	a, err := getDefaultAnalyzer()
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeEntities(a, text, entities)
+}
+
+// joinParts joins sentences and clauses into one text, ending each with a
+// period if it has no final punctuation.
+func joinParts(parts []string) string {
+	var b strings.Builder
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p)
+		if !strings.ContainsAny(p[len(p)-1:], ".!?") {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// splitSentences splits at ., ! and ? followed by whitespace, and at line
+// breaks, except after common abbreviations such as "Ltd." and "Rs.".
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	emit := func(end int) {
+		if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '\n':
+			emit(i + 1)
+		case r == '.' || r == '!' || r == '?':
+			if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+				continue // 3.5, U.S.
+			}
+			if r == '.' {
+				word := lastWord(runes[start:i])
+				if _, found := sentenceAbbreviations[strings.ToLower(word)]; found {
+					continue
+				}
+			}
+			emit(i + 1)
+		}
+	}
+	emit(len(runes))
+	return sentences
+}
+
+func lastWord(runes []rune) string {
+	end := len(runes)
+	i := end
+	for i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+		i--
+	}
+	return string(runes[i:end])
+}
+
+// splitClauses separates the statements of a sentence that names an entity,
+// so that "Analysts cheered the dividend but Wipro fell" is not all about
+// Wipro. Markers inside an alias ("Larsen and Toubro") are ignored.
+func splitClauses(sentence string, entities []Entity) []string {
+	if len(mentionedEntities(sentence, entities)) == 0 {
+		return []string{sentence}
+	}
+	spans := aliasSpans(sentence, entities)
+
+	var clauses []string
+	for _, strong := range splitAt(sentence, strongClauseMarker, spans) {
+		if len(mentionedEntities(strong, entities)) < 2 {
+			clauses = append(clauses, strong)
+			continue
+		}
+		// Split at weak markers only between statements: parts that are
+		// just names ("Infosys, Wipro and HCL fell") join the part after
+		// them, and parts without an entity join the part before.
+		var merged []string
+		pending := ""
+		for _, part := range splitAt(strong, weakClauseMarker, aliasSpans(strong, entities)) {
+			if !hasOwnWords(part, entities) {
+				pending += part + ", "
+				continue
+			}
+			part, pending = pending+part, ""
+			if len(merged) > 0 && len(mentionedEntities(part, entities)) == 0 {
+				merged[len(merged)-1] += ", " + part
+				continue
+			}
+			merged = append(merged, part)
+		}
+		if pending != "" {
+			pending = strings.TrimSuffix(pending, ", ")
+			if len(merged) == 0 {
+				merged = append(merged, pending)
+			} else {
+				merged[len(merged)-1] += ", " + pending
+			}
+		}
+		clauses = append(clauses, merged...)
+	}
+	return clauses
+}
+
+// hasOwnWords reports whether text has words besides entity names.
+func hasOwnWords(text string, entities []Entity) bool {
+	spans := aliasSpans(text, entities)
+	inWord := false
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && !inWord && !overlapsAny(i, i+1, spans) {
+			return true
+		}
+		inWord = isWord
+	}
+	return false
+}
+
+// splitAt splits s at matches of marker that don't overlap a protected span.
+func splitAt(s string, marker *regexp.Regexp, protected [][2]int) []string {
+	var parts []string
+	start := 0
+	for _, m := range marker.FindAllStringIndex(s, -1) {
+		if overlapsAny(m[0], m[1], protected) {
+			continue
+		}
+		if part := strings.TrimSpace(s[start:m[0]]); part != "" {
+			parts = append(parts, part)
+		}
+		start = m[1]
+	}
+	if part := strings.TrimSpace(s[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func overlapsAny(start, end int, spans [][2]int) bool {
+	for _, sp := range spans {
+		if start < sp[1] && sp[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionedEntities returns the indexes of the entities text names.
+func mentionedEntities(text string, entities []Entity) []int {
+	var found []int
+	for i, e := range entities {
+		for _, alias := range e.names() {
+			if len(aliasMatches(text, alias)) > 0 {
+				found = append(found, i)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// aliasSpans returns the byte ranges of all alias matches in text.
+func aliasSpans(text string, entities []Entity) [][2]int {
+	var spans [][2]int
+	for _, e := range entities {
+		for _, alias := range e.names() {
+			spans = append(spans, aliasMatches(text, alias)...)
+		}
+	}
+	return spans
+}
+
+// aliasMatches finds alias in text on word boundaries, ignoring case, and
+// returns the byte ranges of the matches in text. Matching is done rune by
+// rune on text itself rather than on a lowercased copy, whose offsets differ
+// when lowercasing changes a rune's length ("İ", "Ⱥ").
+func aliasMatches(text, alias string) [][2]int {
+	if alias == "" {
+		return nil
+	}
+	var found [][2]int
+	for start := range text {
+		if !isWordBoundary(text, start) {
+			continue
+		}
+		end := matchFold(text, start, alias)
+		if end >= 0 && isWordBoundary(text, end) {
+			found = append(found, [2]int{start, end})
+		}
+	}
+	return found
+}
+
+// matchFold returns the end of alias if it matches text at start under
+// Unicode case folding, or -1.
+func matchFold(text string, start int, alias string) int {
+	i := start
+	for _, a := range alias {
+		if i >= len(text) {
+			return -1
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !equalFoldRune(r, a) {
+			return -1
+		}
+		i += size
+	}
+	return i
+}
+
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// isWordBoundary reports whether the byte offset i of text, which starts a
+// rune or is len(text), is not inside a word. Only letters, digits and
+// combining marks form words, so punctuation such as "’" and spaces such as
+// U+00A0 separate words like their ASCII counterparts.
+func isWordBoundary(text string, i int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i:])
+	return !isWordRune(before) || !isWordRune(after)
+}
+
+// isWordRune reports whether r is part of a word. utf8.RuneError, which
+// stands for the start or end of text, is not.
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r))
+}
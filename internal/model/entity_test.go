@@ -0,0 +1,87 @@
+package model
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAliasSpans(t *testing.T) {
+	entities := []Entity{
+		{Name: "Tata Steel"},
+		{Name: "Infosys", Aliases: []string{"INFY"}},
+		{Name: "KPIT Technologies", Aliases: []string{"kpit"}},
+	}
+	tests := []struct {
+		name string
+		text string
+		want []string // matched text, in entity and alias order
+	}{
+		{"ascii", "Tata Steel rose while INFY fell", []string{"Tata Steel", "INFY"}},
+		{"any case", "TATA STEEL and infosys", []string{"TATA STEEL", "infosys"}},
+		{"every match", "Infosys: Infosys Ltd said", []string{"Infosys", "Infosys"}},
+		{"word boundaries", "Infosysx and XINFY", nil},
+		// Lowercasing İ and Ⱥ changes their length, which shifted the
+		// offsets of everything after them when matching on ToLower(text).
+		{"longer lowercase before", "İSTANBUL: Tata Steel rose", []string{"Tata Steel"}},
+		{"several before", "ȺȺȺ Infosys and Tata Steel", []string{"Tata Steel", "Infosys"}},
+		{"kelvin sign folds to k", "\u212aPIT shares rose", []string{"\u212aPIT"}},
+		// Non-ASCII punctuation and spaces separate words too.
+		{"curly apostrophe", "Infosys’ margins and INFY’s outlook", []string{"Infosys", "INFY"}},
+		{"no-break spaces", "Shares of\u00a0Tata Steel\u00a0rose", []string{"Tata Steel"}},
+		{"em dash", "Infosys—the bellwether—rose", []string{"Infosys"}},
+		{"non-ASCII letters join words", "ÉInfosys and Tata Steelé", nil},
+		{"combining mark joins words", "Infosys\u0301 rose", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, sp := range aliasSpans(tt.text, entities) {
+				got = append(got, tt.text[sp[0]:sp[1]])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aliasSpans(%q) matched %q, want %q", tt.text, got, tt.want)
+			}
+			if mentioned := len(mentionedEntities(tt.text, entities)) > 0; mentioned != (len(tt.want) > 0) {
+				t.Errorf("mentionedEntities(%q) found an entity = %v", tt.text, mentioned)
+			}
+		})
+	}
+}
+
+func TestSplitClausesNonASCII(t *testing.T) {
+	entities := []Entity{{Name: "Infosys"}, {Name: "Wipro"}}
+	got := splitClauses("ȺȺȺ İndia: Infosys rose, and Wipro fell", entities)
+	want := []string{"ȺȺȺ İndia: Infosys rose", "Wipro fell"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitClauses = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultEntitiesAreUnambiguous(t *testing.T) {
+	entities, err := LoadEntities(filepath.Join("..", "..", DefaultEntitiesPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Reliance Power shares hit the upper circuit", nil},
+		{"ICICI Prudential Life posts higher premiums", nil},
+		{"Lt Gen Sharma takes charge; LT rates steady", nil},
+		{"Reliance Industries rose while RIL bonds held", []string{"Reliance Industries"}},
+		{"ICICI Bank net profit rises 15%", []string{"ICICI Bank"}},
+		{"Order win for L&T (NSE:LT)", []string{"Larsen & Toubro"}},
+		{"Larsen and Toubro bags an order", []string{"Larsen & Toubro"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, idx := range mentionedEntities(tt.text, entities) {
+			got = append(got, entities[idx].Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mentionedEntities(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// LabeledExample is one text with its human label.
+type LabeledExample struct {
+	Text  string `json:"text"`
+	Label string `json:"label"`
+}
+
+// numericLabels maps the -1/0/1 convention used by some datasets to class names.
+var numericLabels = map[string]string{"-1": "negative", "0": "neutral", "1": "positive"}
+
+// LoadLabeledDataset reads examples from a .csv file with "text" and "label"
+// header columns or from a .jsonl file with one {"text", "label"} per line.
+// Labels are lowercased; -1, 0 and 1 are read as negative, neutral and positive.
+func LoadLabeledDataset(path string) ([]LabeledExample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var examples []LabeledExample
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		examples, err = readLabeledCSV(f)
+	case ".jsonl", ".ndjson":
+		examples, err = readLabeledJSONL(f)
+	default:
+		return nil, fmt.Errorf("unsupported dataset format %q, use .csv or .jsonl", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset %s: %w", path, err)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("dataset %s has no examples", path)
+	}
+	for i := range examples {
+		examples[i].Label = normalizeLabel(examples[i].Label)
+	}
+	return examples, nil
+}
+
+func readLabeledCSV(r io.Reader) ([]LabeledExample, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	textCol, labelCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "text", "sentence", "headline":
+			textCol = i
+		case "label", "sentiment":
+			labelCol = i
+		}
+	}
+	if textCol < 0 || labelCol < 0 {
+		return nil, fmt.Errorf("header must have text and label columns, got %v", header)
+	}
+
+	var examples []LabeledExample
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return examples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if textCol >= len(rec) || labelCol >= len(rec) {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: missing columns", line)
+		}
+		examples = append(examples, LabeledExample{Text: rec[textCol], Label: rec[labelCol]})
+	}
+}
+
+func readLabeledJSONL(r io.Reader) ([]LabeledExample, error) {
+	var examples []LabeledExample
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		// Labels may be strings or numbers.
+		var rec struct {
+			Text  string          `json:"text"`
+			Label json.RawMessage `json:"label"`
+		}
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		label := strings.Trim(string(rec.Label), `"`)
+		examples = append(examples, LabeledExample{Text: rec.Text, Label: label})
+	}
+	return examples, sc.Err()
+}
+
+func normalizeLabel(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if name, found := numericLabels[label]; found {
+		return name
+	}
+	return label
+}
+
+// ClassMetrics are the one-vs-rest scores for one label.
+type ClassMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"` // examples with this gold label
+}
+
+// ReliabilityBin groups predictions by confidence for the reliability
+// diagram behind the expected calibration error.
+type ReliabilityBin struct {
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Count      int     `json:"count"`
+	Confidence float64 `json:"confidence"` // mean confidence of the bin
+	Accuracy   float64 `json:"accuracy"`   // fraction of the bin predicted correctly
+}
+
+// LatencyStats summarizes per-text scoring time.
+type LatencyStats struct {
+	MeanMs         float64 `json:"mean_ms"`
+	P50Ms          float64 `json:"p50_ms"`
+	P95Ms          float64 `json:"p95_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+	TextsPerSecond float64 `json:"texts_per_second"`
+}
+
+// EvalReport is the result of Evaluate.
+type EvalReport struct {
+	Model        string    `json:"model"`
+	ModelVersion string    `json:"model_version,omitempty"`
+	Dataset      string    `json:"dataset,omitempty"`
+	EvaluatedAt  time.Time `json:"evaluated_at"`
+	Examples     int       `json:"examples"`
+	Errors       int       `json:"errors"` // texts the analyzer failed on, excluded from the metrics
+
+	Accuracy  float64                 `json:"accuracy"`
+	MacroF1   float64                 `json:"macro_f1"`
+	PerClass  map[string]ClassMetrics `json:"per_class"`
+	Labels    []string                `json:"labels"`    // row and column order of Confusion
+	Confusion [][]int                 `json:"confusion"` // Confusion[gold][predicted]
+
+	ECE         float64          `json:"ece"`   // expected calibration error over Reliability
+	Brier       float64          `json:"brier"` // mean squared error of the probabilities against the gold label
+	Reliability []ReliabilityBin `json:"reliability"`
+	Latency     LatencyStats     `json:"latency"`
+}
+
+// EvalOptions configures Evaluate.
+type EvalOptions struct {
+	Dataset string // name recorded in the report
+	Long    bool   // score with AnalyzeLong instead of Analyze
+	Bins    int    // reliability bins, 10 if zero
+}
+
+// Evaluate scores every example one at a time, so that latency is measured
+// per text, and compares the predictions with the gold labels.
+func Evaluate(analyzer SentimentAnalyzer, examples []LabeledExample, opts EvalOptions) (*EvalReport, error) {
+	if len(examples) == 0 {
+		return nil, errors.New("no examples to evaluate")
+	}
+	analyze := analyzer.Analyze
+	if opts.Long {
+		analyze = analyzer.AnalyzeLong
+	}
+
+	report := &EvalReport{Dataset: opts.Dataset, EvaluatedAt: time.Now(), Examples: len(examples)}
+	var gold []string
+	var preds []SentimentResult
+	var latencies []time.Duration
+	start := time.Now()
+	for _, ex := range examples {
+		t := time.Now()
+		res, err := analyze(ex.Text)
+		latencies = append(latencies, time.Since(t))
+		if err != nil {
+			report.Errors++
+			continue
+		}
+		report.Model, report.ModelVersion = res.Model, res.ModelVersion
+		gold = append(gold, ex.Label)
+		preds = append(preds, res)
+	}
+	if len(preds) == 0 {
+		return nil, fmt.Errorf("the analyzer failed on all %d examples", len(examples))
+	}
+
+	report.scoreClasses(gold, preds)
+	report.Reliability, report.ECE = Reliability(gold, preds, opts.Bins)
+	report.Brier = brierScore(report.Labels, gold, preds)
+	report.Latency = latencyStats(latencies, time.Since(start))
+	return report, nil
+}
+
+// scoreClasses fills in accuracy, the confusion matrix and per-class metrics.
+func (r *EvalReport) scoreClasses(gold []string, preds []SentimentResult) {
+	labelSet := make(map[string]struct{})
+	for i := range gold {
+		labelSet[gold[i]] = struct{}{}
+		labelSet[preds[i].Label] = struct{}{}
+		for label := range preds[i].Probabilities {
+			labelSet[label] = struct{}{}
+		}
+	}
+	for label := range labelSet {
+		r.Labels = append(r.Labels, label)
+	}
+	sort.Strings(r.Labels)
+	index := make(map[string]int, len(r.Labels))
+	for i, label := range r.Labels {
+		index[label] = i
+	}
+
+	r.Confusion = make([][]int, len(r.Labels))
+	for i := range r.Confusion {
+		r.Confusion[i] = make([]int, len(r.Labels))
+	}
+	correct := 0
+	for i := range gold {
+		r.Confusion[index[gold[i]]][index[preds[i].Label]]++
+		if gold[i] == preds[i].Label {
+			correct++
+		}
+	}
+	r.Accuracy = float64(correct) / float64(len(gold))
+
+	// Macro-F1 averages over labels present in the gold data only, so that a
+	// model label the dataset never uses doesn't count as a zero.
+	r.PerClass = make(map[string]ClassMetrics, len(r.Labels))
+	goldClasses := 0
+	for i, label := range r.Labels {
+		tp := r.Confusion[i][i]
+		predicted, support := 0, 0
+		for j := range r.Labels {
+			predicted += r.Confusion[j][i]
+			support += r.Confusion[i][j]
+		}
+		m := ClassMetrics{Support: support}
+		if predicted > 0 {
+			m.Precision = float64(tp) / float64(predicted)
+		}
+		if support > 0 {
+			m.Recall = float64(tp) / float64(support)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		r.PerClass[label] = m
+		if support > 0 {
+			r.MacroF1 += m.F1
+			goldClasses++
+		}
+	}
+	if goldClasses > 0 {
+		r.MacroF1 /= float64(goldClasses)
+	}
+}
+
+// Reliability bins predictions by confidence and returns the bins with the
+// expected calibration error: the count-weighted mean of |accuracy - confidence|.
+func Reliability(gold []string, preds []SentimentResult, bins int) ([]ReliabilityBin, float64) {
+	if bins <= 0 {
+		bins = 10
+	}
+	out := make([]ReliabilityBin, bins)
+	for i := range out {
+		out[i].Lower = float64(i) / float64(bins)
+		out[i].Upper = float64(i+1) / float64(bins)
+	}
+	for i, p := range preds {
+		b := int(float64(p.Confidence) * float64(bins))
+		if b >= bins {
+			b = bins - 1
+		}
+		out[b].Count++
+		out[b].Confidence += float64(p.Confidence)
+		if p.Label == gold[i] {
+			out[b].Accuracy++
+		}
+	}
+
+	ece := 0.0
+	for i := range out {
+		if out[i].Count == 0 {
+			continue
+		}
+		out[i].Confidence /= float64(out[i].Count)
+		out[i].Accuracy /= float64(out[i].Count)
+		ece += float64(out[i].Count) / float64(len(preds)) * math.Abs(out[i].Accuracy-out[i].Confidence)
+	}
+	return out, ece
+}
+
+// brierScore is the multi-class Brier score: the mean over examples of the
+// squared differences between the probabilities and the one-hot gold label.
+func brierScore(labels, gold []string, preds []SentimentResult) float64 {
+	total := 0.0
+	for i, p := range preds {
+		for _, label := range labels {
+			d := float64(p.Probabilities[label])
+			if label == gold[i] {
+				d--
+			}
+			total += d * d
+		}
+	}
+	return total / float64(len(preds))
+}
+
+func latencyStats(latencies []time.Duration, total time.Duration) LatencyStats {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum time.Duration
+	for _, l := range sorted {
+		sum += l
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	pct := func(p float64) float64 {
+		return ms(sorted[int(math.Ceil(p*float64(len(sorted))))-1])
+	}
+	return LatencyStats{
+		MeanMs:         ms(sum) / float64(len(sorted)),
+		P50Ms:          pct(0.50),
+		P95Ms:          pct(0.95),
+		P99Ms:          pct(0.99),
+		TextsPerSecond: float64(len(sorted)) / total.Seconds(),
+	}
+}
+
+// JSON returns the report serialized for comparing model versions over time.
+func (r *EvalReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// WriteText prints the report as human readable tables.
+func (r *EvalReport) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Model %s %s on %s: %d examples, %d errors\n", r.Model, r.ModelVersion, r.Dataset, r.Examples, r.Errors)
+	fmt.Fprintf(w, "Accuracy %.4f | macro-F1 %.4f | ECE %.4f | Brier %.4f\n", r.Accuracy, r.MacroF1, r.ECE, r.Brier)
+	fmt.Fprintf(w, "Latency mean %.2fms | p50 %.2fms | p95 %.2fms | p99 %.2fms | %.1f texts/s\n\n",
+		r.Latency.MeanMs, r.Latency.P50Ms, r.Latency.P95Ms, r.Latency.P99Ms, r.Latency.TextsPerSecond)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tPRECISION\tRECALL\tF1\tSUPPORT")
+	for _, label := range r.Labels {
+		m := r.PerClass[label]
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\t%d\n", label, m.Precision, m.Recall, m.F1, m.Support)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprint(tw, "GOLD \\ PREDICTED")
+	for _, label := range r.Labels {
+		fmt.Fprintf(tw, "\t%s", label)
+	}
+	fmt.Fprintln(tw)
+	for i, label := range r.Labels {
+		fmt.Fprint(tw, label)
+		for _, n := range r.Confusion[i] {
+			fmt.Fprintf(tw, "\t%d", n)
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "CONFIDENCE\tCOUNT\tMEAN CONFIDENCE\tACCURACY")
+	for _, b := range r.Reliability {
+		if b.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%.1f-%.1f\t%d\t%.4f\t%.4f\n", b.Lower, b.Upper, b.Count, b.Confidence, b.Accuracy)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fixtureAnalyzer builds results from fixed probabilities per text, ordered
+// like def.Labels, the way a model does. Other texts fail.
+type fixtureAnalyzer struct {
+	def   ModelDefinition
+	probs map[string][]float32
+}
+
+func (a fixtureAnalyzer) Analyze(text string) (SentimentResult, error) {
+	probs, found := a.probs[text]
+	if !found {
+		return SentimentResult{}, fmt.Errorf("no probabilities for %q", text)
+	}
+	return newSentimentResult(a.def, probs, false, 0), nil
+}
+
+func (a fixtureAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return a.Analyze(text)
+}
+
+func (a fixtureAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = a.Analyze(text)
+	}
+	return results
+}
+
+func (a fixtureAnalyzer) Close() error { return nil }
+
+func TestEvaluateFixture(t *testing.T) {
+	analyzer := fixtureAnalyzer{
+		def: ModelDefinition{Name: "stub", Version: "1", Labels: []string{"negative", "neutral", "positive"}},
+		probs: map[string][]float32{
+			"a": {0.1, 0.1, 0.8}, // positive, right
+			"b": {0.2, 0.6, 0.2}, // neutral, wrong
+			"c": {0.7, 0.2, 0.1}, // negative, right
+			"d": {0.1, 0.5, 0.4}, // neutral, right
+			"e": {0.3, 0.1, 0.6}, // positive, wrong
+		},
+	}
+	examples := []LabeledExample{
+		{"a", "positive"},
+		{"b", "positive"},
+		{"c", "negative"},
+		{"d", "neutral"},
+		{"e", "negative"},
+		{"f", "neutral"}, // the analyzer fails on it
+	}
+	r, err := Evaluate(analyzer, examples, EvalOptions{Dataset: "fixture", Bins: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	near := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	if r.Model != "stub" || r.ModelVersion != "1" || r.Examples != 6 || r.Errors != 1 {
+		t.Errorf("model %s %s, %d examples, %d errors", r.Model, r.ModelVersion, r.Examples, r.Errors)
+	}
+	near("accuracy", r.Accuracy, 3.0/5)
+
+	if !slices.Equal(r.Labels, []string{"negative", "neutral", "positive"}) {
+		t.Fatalf("labels = %v", r.Labels)
+	}
+	wantConfusion := [][]int{
+		{1, 0, 1},
+		{0, 1, 0},
+		{0, 1, 1},
+	}
+	for i := range wantConfusion {
+		if !slices.Equal(r.Confusion[i], wantConfusion[i]) {
+			t.Errorf("confusion row %s = %v, want %v", r.Labels[i], r.Confusion[i], wantConfusion[i])
+		}
+	}
+
+	wantClasses := map[string]ClassMetrics{
+		"negative": {Precision: 1, Recall: 0.5, F1: 2.0 / 3, Support: 2},
+		"neutral":  {Precision: 0.5, Recall: 1, F1: 2.0 / 3, Support: 1},
+		"positive": {Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 2},
+	}
+	for label, want := range wantClasses {
+		got := r.PerClass[label]
+		near(label+" precision", got.Precision, want.Precision)
+		near(label+" recall", got.Recall, want.Recall)
+		near(label+" F1", got.F1, want.F1)
+		if got.Support != want.Support {
+			t.Errorf("%s support = %d, want %d", label, got.Support, want.Support)
+		}
+	}
+	near("macro-F1", r.MacroF1, (2.0/3+2.0/3+0.5)/3)
+
+	// Bins of 0.2: d at 0.5; b, c and e at 0.6-0.7 with one right; a at 0.8.
+	wantBins := []ReliabilityBin{
+		{Lower: 0, Upper: 0.2},
+		{Lower: 0.2, Upper: 0.4},
+		{Lower: 0.4, Upper: 0.6, Count: 1, Confidence: 0.5, Accuracy: 1},
+		{Lower: 0.6, Upper: 0.8, Count: 3, Confidence: 1.9 / 3, Accuracy: 1.0 / 3},
+		{Lower: 0.8, Upper: 1, Count: 1, Confidence: 0.8, Accuracy: 1},
+	}
+	for i, want := range wantBins {
+		got := r.Reliability[i]
+		if got.Count != want.Count {
+			t.Errorf("bin %d has %d predictions, want %d", i, got.Count, want.Count)
+		}
+		near(fmt.Sprintf("bin %d confidence", i), got.Confidence, want.Confidence)
+		near(fmt.Sprintf("bin %d accuracy", i), got.Accuracy, want.Accuracy)
+	}
+	near("ECE", r.ECE, 1.0/5*0.5+3.0/5*0.3+1.0/5*0.2)
+
+	// Squared errors per example: 0.06, 1.04, 0.14, 0.42 and 0.86.
+	near("Brier", r.Brier, 2.52/5)
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Model stub 1 on fixture: 6 examples, 1 errors",
+		"Accuracy 0.6000 | macro-F1 0.6111 | ECE 0.3200 | Brier 0.5040",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestEvaluateAllFail(t *testing.T) {
+	analyzer := fixtureAnalyzer{def: ModelDefinition{Labels: []string{"negative", "positive"}}}
+	if _, err := Evaluate(analyzer, []LabeledExample{{"a", "positive"}}, EvalOptions{}); err == nil {
+		t.Error("evaluation succeeded with every example failing")
+	}
+	if _, err := Evaluate(analyzer, nil, EvalOptions{}); err == nil {
+		t.Error("evaluation succeeded without examples")
+	}
+}
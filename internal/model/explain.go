@@ -0,0 +1,270 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxExplainWords caps the leave-one-out runs per explanation; later words
+// get no attribution.
+const maxExplainWords = 128
+
+// WordAttribution is how much one word of the text contributed to the
+// predicted label: the label's probability with the word minus without it.
+// Positive values support the prediction, negative ones argue against it.
+type WordAttribution struct {
+	Word        string   `json:"word"`
+	Start       int      `json:"start"` // byte offsets in the explained text
+	End         int      `json:"end"`
+	Tokens      []string `json:"tokens,omitempty"` // the word's WordPiece tokens, for transformer models
+	Attribution float32  `json:"attribution"`
+}
+
+// PhraseAttribution is a run of adjacent words pushing the same way.
+type PhraseAttribution struct {
+	Phrase      string  `json:"phrase"`
+	Start       int     `json:"start"`
+	End         int     `json:"end"`
+	Attribution float32 `json:"attribution"` // sum over the phrase's words
+}
+
+// Explanation is a prediction with leave-one-out word attributions.
+type Explanation struct {
+	Text      string              `json:"text"`
+	Result    SentimentResult     `json:"result"`
+	Words     []WordAttribution   `json:"words"`
+	Phrases   []PhraseAttribution `json:"top_phrases"` // strongest first
+	Truncated bool                `json:"truncated"`   // words past the model's input or maxExplainWords were not attributed
+}
+
+// explainer is implemented by analyzers with their own Explain.
+type explainer interface {
+	Explain(text string) (Explanation, error)
+}
+
+// Explain attributes analyzer's prediction for text to its words by leaving
+// out one word at a time and re-scoring. Analyzers that can do this on
+// tokens (the ONNX Analyzer) do so; others re-score the text without each
+// word. Costs one model row per word, run in batches.
+func Explain(analyzer SentimentAnalyzer, text string) (Explanation, error) {
+	if e, ok := analyzer.(explainer); ok {
+		return e.Explain(text)
+	}
+
+	words := explainWords(text)
+	base, err := analyzer.Analyze(text)
+	if err != nil {
+		return Explanation{}, err
+	}
+	exp := Explanation{Text: text, Result: base, Words: words}
+	if len(words) > maxExplainWords {
+		exp.Words, exp.Truncated = words[:maxExplainWords], true
+	}
+
+	variants := make([]string, len(exp.Words))
+	for i, w := range exp.Words {
+		variants[i] = strings.Join(strings.Fields(text[:w.Start]+" "+text[w.End:]), " ")
+	}
+	for i, res := range analyzer.AnalyzeBatch(variants) {
+		// A text that is a single word has nothing left once it is removed;
+		// the whole prediction is then attributed to that word.
+		if res.Err != nil && strings.TrimSpace(variants[i]) != "" {
+			return Explanation{}, res.Err
+		}
+		exp.Words[i].Attribution = base.Confidence - res.Probability(base.Label)
+	}
+	exp.Phrases = topPhrases(text, exp.Words, 5)
+	return exp, nil
+}
+
+// Explain runs the leave-one-out explanation on WordPiece tokens with the
+// model's own sessions: each variant drops one word's tokens.
+func (a *Analyzer) Explain(text string) (Explanation, error) {
+	if strings.TrimSpace(text) == "" {
+		return Explanation{}, ErrEmptyText
+	}
+	words := explainWords(text)
+	var pieces []string
+	for i := range words {
+		words[i].Tokens = a.tokenizer.Tokenize(words[i].Word)
+		pieces = append(pieces, words[i].Tokens...)
+	}
+
+	base := a.tokenizer.encodePieces(pieces)
+	inputs := []TokenizedOutput{base}
+
+	// Only words whose tokens fit in the model's input can be left out.
+	exp := Explanation{Text: text}
+	limit, used := 0, 0
+	for _, w := range words {
+		if limit == maxExplainWords || used+len(w.Tokens) > a.def.MaxLength-2 {
+			exp.Truncated = true
+			break
+		}
+		used += len(w.Tokens)
+		limit++
+	}
+	exp.Words = words[:limit]
+
+	offset := 0
+	for _, w := range exp.Words {
+		rest := append(append([]string(nil), pieces[:offset]...), pieces[offset+len(w.Tokens):]...)
+		inputs = append(inputs, a.tokenizer.encodePieces(rest))
+		offset += len(w.Tokens)
+	}
+
+	var probs [][]float32
+	for start := 0; start < len(inputs); start += a.def.BatchSize {
+		end := start + a.def.BatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		logits, err := a.run(inputs[start:end])
+		if err != nil {
+			return Explanation{}, err
+		}
+		for _, l := range logits {
+			probs = append(probs, softmax(l))
+		}
+	}
+
+	exp.Result = newSentimentResult(a.def, probs[0], base.Truncated, 0)
+	for i := range exp.Words {
+		without := newSentimentResult(a.def, probs[i+1], false, 0)
+		exp.Words[i].Attribution = exp.Result.Confidence - without.Probability(exp.Result.Label)
+	}
+	exp.Phrases = topPhrases(text, exp.Words, 5)
+	return exp, nil
+}
+
+// explainWords splits text at whitespace, keeping byte offsets. Tokenizing
+// each word separately gives the same WordPieces as the whole text, since
+// BERT's basic tokenizer splits at whitespace first.
+func explainWords(text string) []WordAttribution {
+	var words []WordAttribution
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, WordAttribution{Word: text[start:i], Start: start, End: i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, WordAttribution{Word: text[start:], Start: start, End: len(text)})
+	}
+	return words
+}
+
+// minPhraseAttribution ignores words whose removal barely moves the prediction.
+const minPhraseAttribution = 0.01
+
+// topPhrases merges adjacent words whose attributions share a sign into
+// phrases and returns the n with the largest absolute attribution.
+func topPhrases(text string, words []WordAttribution, n int) []PhraseAttribution {
+	var phrases []PhraseAttribution
+	for i := 0; i < len(words); {
+		a := words[i].Attribution
+		if math.Abs(float64(a)) < minPhraseAttribution {
+			i++
+			continue
+		}
+		p := PhraseAttribution{Start: words[i].Start, End: words[i].End, Attribution: a}
+		j := i + 1
+		for ; j < len(words); j++ {
+			b := words[j].Attribution
+			if math.Abs(float64(b)) < minPhraseAttribution || (a > 0) != (b > 0) {
+				break
+			}
+			p.End = words[j].End
+			p.Attribution += b
+		}
+		p.Phrase = text[p.Start:p.End]
+		phrases = append(phrases, p)
+		i = j
+	}
+	sort.SliceStable(phrases, func(i, j int) bool {
+		return math.Abs(float64(phrases[i].Attribution)) > math.Abs(float64(phrases[j].Attribution))
+	})
+	if len(phrases) > n {
+		phrases = phrases[:n]
+	}
+	return phrases
+}
+
+// ExplainSentiment explains a prediction of the shared analyzer.
+func ExplainSentiment(text string) (Explanation, error) {
+	a, err := getDefaultAnalyzer()
+	if err != nil {
+		return Explanation{}, err
+	}
+	return Explain(a, text)
+}
+
+// JSON returns the explanation serialized.
+func (e Explanation) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// ANSI styles for WriteHighlighted.
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+)
+
+// WriteHighlighted prints the text with words that support the predicted
+// label in the label's color (bold when strong) and words against it in
+// blue, followed by the top phrases.
+func (e Explanation) WriteHighlighted(w io.Writer) error {
+	labelColor := ansiYellow
+	switch e.Result.Label {
+	case "positive":
+		labelColor = ansiGreen
+	case "negative":
+		labelColor = ansiRed
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, word := range e.Words {
+		b.WriteString(e.Text[last:word.Start])
+		switch a := word.Attribution; {
+		case a >= 0.1:
+			b.WriteString(ansiBold + labelColor + word.Word + ansiReset)
+		case a >= 0.02:
+			b.WriteString(labelColor + word.Word + ansiReset)
+		case a <= -0.02:
+			b.WriteString(ansiBlue + word.Word + ansiReset)
+		default:
+			b.WriteString(word.Word)
+		}
+		last = word.End
+	}
+	b.WriteString(e.Text[last:])
+
+	fmt.Fprintf(w, "Why %s%s%s (%.2f): %s\n", labelColor, e.Result.Label, ansiReset, e.Result.Confidence, b.String())
+	for _, p := range e.Phrases {
+		direction := "for"
+		if p.Attribution < 0 {
+			direction = "against"
+		}
+		fmt.Fprintf(w, "  %+.3f %-7s %q\n", p.Attribution, direction, p.Phrase)
+	}
+	if e.Truncated {
+		_, err := fmt.Fprintln(w, "  (later words were not attributed)")
+		return err
+	}
+	return nil
+}
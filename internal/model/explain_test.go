@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainLeaveOneOut(t *testing.T) {
+	lexicon := NewLexiconAnalyzer(ModelDefinition{})
+	text := "Tata Motors’  posts massive losses,\tdespite strong demand"
+	exp, err := Explain(lexicon, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Result.Label != "negative" || exp.Truncated {
+		t.Fatalf("explained %s, truncated %v; want an untruncated negative prediction", exp.Result.Label, exp.Truncated)
+	}
+
+	// Attributions line up with the words of the text, in order.
+	fields := strings.Fields(text)
+	if len(exp.Words) != len(fields) {
+		t.Fatalf("%d attributions for %d words", len(exp.Words), len(fields))
+	}
+	attribution := make(map[string]float32)
+	for i, w := range exp.Words {
+		if w.Word != fields[i] || text[w.Start:w.End] != w.Word {
+			t.Errorf("word %d = %q at %d-%d (%q), want %q", i, w.Word, w.Start, w.End, text[w.Start:w.End], fields[i])
+		}
+		attribution[w.Word] = w.Attribution
+	}
+
+	// Removing the negative word flips the prediction, so it carries the
+	// largest attribution; the positive word argues against the prediction
+	// and words outside the lexicon don't matter.
+	for _, w := range exp.Words {
+		if w.Word != "losses," && w.Attribution >= attribution["losses,"] {
+			t.Errorf("%q attribution %v not below losses' %v", w.Word, w.Attribution, attribution["losses,"])
+		}
+	}
+	if attribution["massive"] <= 0 {
+		t.Errorf("intensifier attribution %v, want positive", attribution["massive"])
+	}
+	if attribution["strong"] >= 0 {
+		t.Errorf("positive word attribution %v, want negative", attribution["strong"])
+	}
+	for _, word := range []string{"Tata", "Motors’", "posts", "despite", "demand"} {
+		if attribution[word] != 0 {
+			t.Errorf("%q attribution %v, want 0", word, attribution[word])
+		}
+	}
+
+	// Each attribution is the predicted label's probability with the word
+	// minus without it.
+	without, err := lexicon.Analyze("Tata Motors’ posts massive despite strong demand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := exp.Result.Confidence - without.Probability("negative"); attribution["losses,"] != want {
+		t.Errorf("losses attribution %v, want %v", attribution["losses,"], want)
+	}
+
+	if len(exp.Phrases) == 0 || exp.Phrases[0].Phrase != "massive losses," {
+		t.Fatalf("top phrases %+v, want \"massive losses,\" first", exp.Phrases)
+	}
+	if got, want := exp.Phrases[0].Attribution, attribution["massive"]+attribution["losses,"]; got != want {
+		t.Errorf("phrase attribution %v, want %v", got, want)
+	}
+}
+
+func TestExplainSingleWord(t *testing.T) {
+	exp, err := Explain(NewLexiconAnalyzer(ModelDefinition{}), "fraud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exp.Words) != 1 || exp.Words[0].Attribution != exp.Result.Confidence {
+		t.Errorf("words %+v, want the whole confidence %v on the only word", exp.Words, exp.Result.Confidence)
+	}
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexConfig sets how SentimentIndex weighs articles, in the index section
+// of configs/model.yaml.
+type IndexConfig struct {
+	HalfLife     time.Duration      `yaml:"half_life"`     // age at which an article counts half
+	MaxAge       time.Duration      `yaml:"max_age"`       // older articles are ignored
+	DefaultTrust float64            `yaml:"default_trust"` // for sources without a trust of their own
+	SourceTrust  map[string]float64 `yaml:"source_trust"`  // by lowercase source name or domain
+	NoveltyDecay float64            `yaml:"novelty_decay"` // weight factor per earlier article of the same story
+}
+
+func (c *IndexConfig) applyDefaults() {
+	if c.HalfLife <= 0 {
+		c.HalfLife = 6 * time.Hour
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = 5 * c.HalfLife
+	}
+	if c.DefaultTrust <= 0 {
+		c.DefaultTrust = 0.5
+	}
+	if c.NoveltyDecay <= 0 || c.NoveltyDecay > 1 {
+		c.NoveltyDecay = 0.5
+	}
+}
+
+// IndexedArticle is one scored article counted towards a ticker's index.
+type IndexedArticle struct {
+	Ticker      string          `json:"ticker"`
+	Source      string          `json:"source"`
+	StoryID     string          `json:"story_id,omitempty"` // articles of one story share it; empty means a story of its own
+	PublishedAt time.Time       `json:"published_at"`
+	AvailableAt time.Time       `json:"available_at"` // when the bot had the article scored, PublishedAt if zero
+	Trust       float64         `json:"trust"`        // 0 takes the source's configured trust
+	Sentiment   SentimentResult `json:"sentiment"`
+}
+
+// IndexValue is a ticker's sentiment index at one point in time.
+type IndexValue struct {
+	Ticker     string    `json:"ticker"`
+	Time       time.Time `json:"time"`
+	Value      float64   `json:"value"`      // weighted mean score in [-1, 1], 0 without articles
+	Articles   int       `json:"articles"`   // articles within MaxAge
+	Stories    int       `json:"stories"`    // distinct stories among them
+	Weight     float64   `json:"weight"`     // sum of the article weights
+	Effective  float64   `json:"effective"`  // effective number of articles given the weights
+	Dispersion float64   `json:"dispersion"` // weighted standard deviation of the scores
+}
+
+// SentimentIndex combines scored articles into a time-decayed sentiment
+// index per ticker. Each article's score, p(positive) - p(negative), is
+// weighted by
+//
+//	0.5^(age/HalfLife) * trust * NoveltyDecay^(earlier articles of its story)
+//
+// so repeats of one story by many outlets don't count as many events. Only
+// articles within MaxAge count, both as events and as earlier articles.
+// Values are computed on demand from the articles available at the queried
+// time only, so the same index serves live trading and backtests without
+// look-ahead.
+type SentimentIndex struct {
+	cfg IndexConfig
+
+	mu       sync.RWMutex
+	articles map[string][]IndexedArticle // by ticker, sorted by AvailableAt
+}
+
+// NewSentimentIndex creates an empty index. Zero config values take defaults:
+// a 6 hour half-life, five half-lives of history, trust 0.5 for unknown
+// sources and half weight per repeat of a story.
+func NewSentimentIndex(cfg IndexConfig) *SentimentIndex {
+	cfg.applyDefaults()
+	trust := make(map[string]float64, len(cfg.SourceTrust))
+	for source, t := range cfg.SourceTrust {
+		trust[normalizeSource(source)] = t
+	}
+	cfg.SourceTrust = trust
+	return &SentimentIndex{cfg: cfg, articles: make(map[string][]IndexedArticle)}
+}
+
+// Config returns the index's configuration with defaults applied.
+func (x *SentimentIndex) Config() IndexConfig {
+	return x.cfg
+}
+
+// Add records a scored article. Articles may arrive out of order.
+func (x *SentimentIndex) Add(a IndexedArticle) error {
+	if a.Ticker == "" {
+		return errors.New("article has no ticker")
+	}
+	if a.PublishedAt.IsZero() {
+		return errors.New("article has no publish time")
+	}
+	if a.AvailableAt.IsZero() || a.AvailableAt.Before(a.PublishedAt) {
+		a.AvailableAt = a.PublishedAt
+	}
+	if a.Trust <= 0 {
+		a.Trust = x.SourceTrust(a.Source)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	list := x.articles[a.Ticker]
+	i := sort.Search(len(list), func(i int) bool { return list[i].AvailableAt.After(a.AvailableAt) })
+	list = append(list, IndexedArticle{})
+	copy(list[i+1:], list[i:])
+	list[i] = a
+	x.articles[a.Ticker] = list
+	return nil
+}
+
+// SourceTrust returns the configured trust of a source, matching a domain
+// by its suffix, or DefaultTrust.
+func (x *SentimentIndex) SourceTrust(source string) float64 {
+	s := normalizeSource(source)
+	if t, found := x.cfg.SourceTrust[s]; found {
+		return t
+	}
+	for known, t := range x.cfg.SourceTrust {
+		if strings.HasSuffix(s, "."+known) {
+			return t
+		}
+	}
+	return x.cfg.DefaultTrust
+}
+
+// At returns the index of ticker at t from the articles available by then.
+func (x *SentimentIndex) At(ticker string, t time.Time) IndexValue {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.valueLocked(ticker, t)
+}
+
+// Series returns the index of ticker at every step from from to to inclusive.
+func (x *SentimentIndex) Series(ticker string, from, to time.Time, step time.Duration) []IndexValue {
+	if step <= 0 || to.Before(from) {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var series []IndexValue
+	for t := from; !t.After(to); t = t.Add(step) {
+		series = append(series, x.valueLocked(ticker, t))
+	}
+	return series
+}
+
+// Snapshot returns the index of every ticker at t, by ticker.
+func (x *SentimentIndex) Snapshot(t time.Time) []IndexValue {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	values := make([]IndexValue, 0, len(x.articles))
+	for ticker := range x.articles {
+		values = append(values, x.valueLocked(ticker, t))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Ticker < values[j].Ticker })
+	return values
+}
+
+// Tickers returns the tickers with articles, sorted.
+func (x *SentimentIndex) Tickers() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	tickers := make([]string, 0, len(x.articles))
+	for ticker := range x.articles {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// Prune drops articles that became available before cutoff. They were
+// published before cutoff, so they are older than MaxAge at and after
+// cutoff+MaxAge and values at those times are unchanged. A live index
+// should be pruned periodically with a cutoff well past MaxAge; a backtest
+// keeps everything.
+func (x *SentimentIndex) Prune(cutoff time.Time) int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	dropped := 0
+	for ticker, list := range x.articles {
+		i := sort.Search(len(list), func(i int) bool { return !list[i].AvailableAt.Before(cutoff) })
+		dropped += i
+		if i == len(list) {
+			delete(x.articles, ticker)
+			continue
+		}
+		x.articles[ticker] = append([]IndexedArticle(nil), list[i:]...)
+	}
+	return dropped
+}
+
+func (x *SentimentIndex) valueLocked(ticker string, t time.Time) IndexValue {
+	v := IndexValue{Ticker: ticker, Time: t}
+	list := x.articles[ticker]
+	// Only articles the bot had by t count, however early they were published.
+	end := sort.Search(len(list), func(i int) bool { return list[i].AvailableAt.After(t) })
+	visible := list[:end]
+
+	// Novelty depends on how many articles of the story were available
+	// earlier and are still within MaxAge, so an expired or pruned article
+	// doesn't discount a later one.
+	seen := make(map[string]int)
+	halfLife := x.cfg.HalfLife.Hours()
+	var sumW, sumW2, sumWS float64
+	type weighted struct{ w, s float64 }
+	var counted []weighted
+	for _, a := range visible {
+		age := t.Sub(a.PublishedAt)
+		if age > x.cfg.MaxAge {
+			continue
+		}
+		if age < 0 {
+			age = 0
+		}
+		repeats := 0
+		if a.StoryID != "" {
+			repeats = seen[a.StoryID]
+			seen[a.StoryID]++
+		}
+		if a.StoryID == "" || repeats == 0 {
+			v.Stories++
+		}
+		v.Articles++
+
+		w := math.Pow(0.5, age.Hours()/halfLife) * a.Trust * math.Pow(x.cfg.NoveltyDecay, float64(repeats))
+		s := float64(a.Sentiment.Score)
+		sumW += w
+		sumW2 += w * w
+		sumWS += w * s
+		counted = append(counted, weighted{w, s})
+	}
+	if sumW == 0 {
+		return v
+	}
+
+	v.Weight = sumW
+	v.Value = sumWS / sumW
+	v.Effective = sumW * sumW / sumW2
+	var variance float64
+	for _, c := range counted {
+		variance += c.w * (c.s - v.Value) * (c.s - v.Value)
+	}
+	v.Dispersion = math.Sqrt(variance / sumW)
+	return v
+}
+
+func normalizeSource(source string) string {
+	s := strings.ToLower(strings.TrimSpace(source))
+	return strings.TrimPrefix(s, "www.")
+}
+
+var (
+	defaultIndexOnce sync.Once
+	defaultIndex     *SentimentIndex
+	defaultIndexErr  error
+)
+
+// DefaultSentimentIndex returns a process-wide index configured by the
+// index section of the shared analyzer's model config.
+func DefaultSentimentIndex() (*SentimentIndex, error) {
+	defaultIndexOnce.Do(func() {
+		a, err := getDefaultAnalyzer()
+		if err != nil {
+			defaultIndexErr = err
+			return
+		}
+		defaultIndex = NewSentimentIndex(a.cfg.Index)
+	})
+	return defaultIndex, defaultIndexErr
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSentimentIndexNovelty(t *testing.T) {
+	x := NewSentimentIndex(IndexConfig{HalfLife: time.Hour, MaxAge: 4 * time.Hour, DefaultTrust: 1})
+	t0 := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	add := func(story string, at time.Time, score float32) {
+		t.Helper()
+		if err := x.Add(IndexedArticle{Ticker: "INFY", Source: "reuters.com", StoryID: story, PublishedAt: at, Sentiment: SentimentResult{Score: score}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("deal", t0, 1)
+	add("deal", t0, 1)
+
+	v := x.At("INFY", t0)
+	if v.Articles != 2 || v.Stories != 1 || math.Abs(v.Weight-1.5) > 1e-9 {
+		t.Errorf("repeat at t0: %+v, want 2 articles of 1 story with weight 1.5", v)
+	}
+
+	// Once the first articles are older than MaxAge, a new article of the
+	// story is novel again.
+	later := t0.Add(5 * time.Hour)
+	add("deal", later, -1)
+	v = x.At("INFY", later)
+	if v.Articles != 1 || v.Stories != 1 || math.Abs(v.Weight-1) > 1e-9 || v.Value != -1 {
+		t.Errorf("after MaxAge: %+v, want 1 novel article with weight 1", v)
+	}
+}
+
+func TestSentimentIndexPruneKeepsValues(t *testing.T) {
+	cfg := IndexConfig{HalfLife: time.Hour, MaxAge: 3 * time.Hour, SourceTrust: map[string]float64{"reuters.com": 1}}
+	t0 := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	articles := []IndexedArticle{
+		{Source: "reuters.com", StoryID: "deal", PublishedAt: t0, Sentiment: SentimentResult{Score: 0.8}},
+		{Source: "blog.example", StoryID: "deal", PublishedAt: t0.Add(30 * time.Minute), AvailableAt: t0.Add(2 * time.Hour), Sentiment: SentimentResult{Score: 0.6}},
+		{Source: "reuters.com", StoryID: "results", PublishedAt: t0.Add(time.Hour), Sentiment: SentimentResult{Score: -0.4}},
+		{Source: "reuters.com", StoryID: "deal", PublishedAt: t0.Add(3 * time.Hour), Sentiment: SentimentResult{Score: 0.9}},
+		{Source: "livemint.com", StoryID: "deal", PublishedAt: t0.Add(4 * time.Hour), Sentiment: SentimentResult{Score: 0.7}},
+		{Source: "reuters.com", PublishedAt: t0.Add(5 * time.Hour), Sentiment: SentimentResult{Score: -0.2}},
+		{Source: "reuters.com", StoryID: "results", PublishedAt: t0.Add(6 * time.Hour), Sentiment: SentimentResult{Score: -0.5}},
+	}
+	full := NewSentimentIndex(cfg)
+	pruned := NewSentimentIndex(cfg)
+	for _, a := range articles {
+		a.Ticker = "INFY"
+		full.Add(a)
+		pruned.Add(a)
+	}
+
+	cutoff := t0.Add(150 * time.Minute)
+	if n := pruned.Prune(cutoff); n != 3 {
+		t.Fatalf("pruned %d articles, want 3", n)
+	}
+	from := cutoff.Add(cfg.MaxAge)
+	for _, at := range []time.Time{from, from.Add(30 * time.Minute), from.Add(time.Hour), from.Add(4 * time.Hour)} {
+		want, got := full.At("INFY", at), pruned.At("INFY", at)
+		if got != want {
+			t.Errorf("at %s: %+v after Prune, %+v before", at.Format(time.Kitchen), got, want)
+		}
+	}
+}
@@ -0,0 +1,513 @@
+package model
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons a text is queued for labelling.
+const (
+	LabelReasonLowConfidence = "low_confidence"      // the model's confidence was under the threshold
+	LabelReasonDisagreement  = "member_disagreement" // ensemble members predicted different labels
+	LabelReasonShadow        = "shadow_disagreement" // a shadow model predicted a different label
+)
+
+// LabelingConfig routes uncertain predictions into the labelling queue, in
+// the labeling section of configs/model.yaml.
+type LabelingConfig struct {
+	Queue         string  `yaml:"queue"`          // JSON lines file, empty disables routing
+	MinConfidence float32 `yaml:"min_confidence"` // predictions less confident than this are queued
+	Disagreement  bool    `yaml:"disagreement"`   // queue texts ensemble members or shadows disagree on
+}
+
+// Enabled reports whether uncertain predictions are queued.
+func (c LabelingConfig) Enabled() bool {
+	return c.Queue != ""
+}
+
+// LabelItem is one text in the labelling queue.
+type LabelItem struct {
+	ID            string             `json:"id"` // hash of the normalized text
+	Text          string             `json:"text"`
+	Reasons       []string           `json:"reasons"`
+	AddedAt       time.Time          `json:"added_at"`
+	Model         string             `json:"model"`
+	ModelVersion  string             `json:"model_version,omitempty"`
+	Predicted     string             `json:"predicted"`
+	Confidence    float32            `json:"confidence"`
+	Uncertainty   float64            `json:"uncertainty"`
+	Probabilities map[string]float32 `json:"probabilities,omitempty"`
+	Candidates    map[string]string  `json:"candidates,omitempty"` // labels predicted by other models, by model
+
+	Label     string    `json:"label,omitempty"` // the human label, empty while pending
+	Skipped   bool      `json:"skipped,omitempty"`
+	Labeler   string    `json:"labeler,omitempty"`
+	LabeledAt time.Time `json:"labeled_at,omitempty"`
+}
+
+// Pending reports whether the item still needs a label.
+func (i LabelItem) Pending() bool {
+	return i.Label == "" && !i.Skipped
+}
+
+// priority orders pending items: texts the models disagree on first, then
+// by uncertainty.
+func (i LabelItem) priority() float64 {
+	p := i.Uncertainty
+	if len(i.Candidates) > 0 {
+		p++
+	}
+	return p
+}
+
+// LabelQueueStats counts the items of a queue.
+type LabelQueueStats struct {
+	Total    int            `json:"total"`
+	Pending  int            `json:"pending"`
+	Labeled  int            `json:"labeled"`
+	Skipped  int            `json:"skipped"`
+	ByLabel  map[string]int `json:"by_label"`
+	ByReason map[string]int `json:"by_reason"`
+}
+
+// LabelQueue is a persistent queue of texts waiting for a human label. It is
+// an append-only JSON lines file in which each line is the latest state of
+// one item, so the bot can add items while a labeler labels them from
+// another process. On load, a labelled or skipped state of an item is never
+// replaced by a pending one.
+type LabelQueue struct {
+	path string
+
+	mu    sync.Mutex
+	items map[string]*LabelItem
+	order []string // IDs in the order they were first added
+	file  *os.File
+}
+
+// OpenLabelQueue loads the queue at path, creating it if needed.
+func OpenLabelQueue(path string) (*LabelQueue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create label queue directory: %w", err)
+	}
+	q := &LabelQueue{path: path}
+	if err := q.Reload(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open label queue: %w", err)
+	}
+	q.file = f
+	return q, nil
+}
+
+// Reload re-reads the queue file to pick up changes made by other processes.
+func (q *LabelQueue) Reload() error {
+	items := make(map[string]*LabelItem)
+	var order []string
+
+	f, err := os.Open(q.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read label queue: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for line := 1; sc.Scan(); line++ {
+			if len(sc.Bytes()) == 0 {
+				continue
+			}
+			var item LabelItem
+			if err := json.Unmarshal(sc.Bytes(), &item); err != nil {
+				// A line cut short by a crash is skipped, not fatal.
+				fmt.Printf("Skipping malformed label queue line %d: %v\n", line, err)
+				continue
+			}
+			old, found := items[item.ID]
+			if !found {
+				order = append(order, item.ID)
+			} else if item.Pending() && !old.Pending() {
+				continue
+			}
+			items[item.ID] = &item
+		}
+		if err := sc.Err(); err != nil {
+			return fmt.Errorf("failed to read label queue: %w", err)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items, q.order = items, order
+	return nil
+}
+
+// Add queues a text unless it is already in the queue, in which case its
+// new reasons and candidate labels are merged into the pending item. It
+// reports whether the queue changed.
+func (q *LabelQueue) Add(item LabelItem) (bool, error) {
+	if item.ID == "" {
+		item.ID = labelItemID(item.Text)
+	}
+	if item.AddedAt.IsZero() {
+		item.AddedAt = time.Now().UTC()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	old, found := q.items[item.ID]
+	if !found {
+		q.items[item.ID] = &item
+		q.order = append(q.order, item.ID)
+		return true, q.appendLocked(item)
+	}
+	if !old.Pending() {
+		return false, nil
+	}
+
+	changed := false
+	for _, r := range item.Reasons {
+		if !containsLabel(old.Reasons, r) {
+			old.Reasons = append(old.Reasons, r)
+			changed = true
+		}
+	}
+	for model, label := range item.Candidates {
+		if old.Candidates == nil {
+			old.Candidates = make(map[string]string)
+		}
+		if old.Candidates[model] != label {
+			old.Candidates[model] = label
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, q.appendLocked(*old)
+}
+
+// SetLabel records the human label of an item.
+func (q *LabelQueue) SetLabel(id, label, labeler string) error {
+	return q.update(id, func(item *LabelItem) {
+		item.Label, item.Skipped = label, false
+		item.Labeler, item.LabeledAt = labeler, time.Now().UTC()
+	})
+}
+
+// Skip marks an item as unlabelable, for example because the text is not
+// news, so that it isn't offered again or exported.
+func (q *LabelQueue) Skip(id, labeler string) error {
+	return q.update(id, func(item *LabelItem) {
+		item.Label, item.Skipped = "", true
+		item.Labeler, item.LabeledAt = labeler, time.Now().UTC()
+	})
+}
+
+func (q *LabelQueue) update(id string, change func(*LabelItem)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	item, found := q.items[id]
+	if !found {
+		return fmt.Errorf("label queue has no item %q", id)
+	}
+	change(item)
+	return q.appendLocked(*item)
+}
+
+// Next returns the pending item most worth labelling: one the models
+// disagree on, else the most uncertain.
+func (q *LabelQueue) Next() (LabelItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var best *LabelItem
+	for _, id := range q.order {
+		item := q.items[id]
+		if item.Pending() && (best == nil || item.priority() > best.priority()) {
+			best = item
+		}
+	}
+	if best == nil {
+		return LabelItem{}, false
+	}
+	return *best, true
+}
+
+// Items returns every item in the order they were added.
+func (q *LabelQueue) Items() []LabelItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := make([]LabelItem, len(q.order))
+	for i, id := range q.order {
+		items[i] = *q.items[id]
+	}
+	return items
+}
+
+// Stats counts the items by state, human label and reason.
+func (q *LabelQueue) Stats() LabelQueueStats {
+	s := LabelQueueStats{ByLabel: make(map[string]int), ByReason: make(map[string]int)}
+	for _, item := range q.Items() {
+		s.Total++
+		switch {
+		case item.Skipped:
+			s.Skipped++
+		case item.Label != "":
+			s.Labeled++
+			s.ByLabel[item.Label]++
+		default:
+			s.Pending++
+		}
+		for _, r := range item.Reasons {
+			s.ByReason[r]++
+		}
+	}
+	return s
+}
+
+// Compact rewrites the queue file with one line per item. It must not run
+// while another process is appending to the queue.
+func (q *LabelQueue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tmp := q.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to compact label queue: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, id := range q.order {
+		if err = enc.Encode(q.items[id]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, q.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact label queue: %w", err)
+	}
+
+	// The old handle points at the replaced file.
+	q.file.Close()
+	q.file, err = os.OpenFile(q.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	return err
+}
+
+// Close closes the queue file.
+func (q *LabelQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
+
+func (q *LabelQueue) appendLocked(item LabelItem) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	// One write per line keeps lines from different processes whole.
+	if _, err := q.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write label queue: %w", err)
+	}
+	return nil
+}
+
+// labelItemID identifies a text independently of whitespace and Unicode
+// normalization, like the result cache.
+func labelItemID(text string) string {
+	sum := sha256.Sum256([]byte(normalizeCacheText(text)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// DatasetExport summarizes an exported dataset.
+type DatasetExport struct {
+	Train           string         `json:"train"`
+	Validation      string         `json:"validation"`
+	TrainCount      int            `json:"train_count"`
+	ValidationCount int            `json:"validation_count"`
+	ByLabel         map[string]int `json:"by_label"`
+	Ignored         int            `json:"ignored"` // labelled with a label the model doesn't have
+}
+
+// Export writes the labelled items to dir/train.csv and dir/validation.csv
+// with "text" and "label" columns, the format scripts/retrain_sentiment.py
+// reads and LoadLabeledDataset accepts. An item goes to validation when a
+// hash of its ID falls under validationFraction, so items keep their split
+// across exports as the queue grows. Items with labels outside labels are
+// ignored; an empty labels accepts every label.
+func (q *LabelQueue) Export(dir string, validationFraction float64, labels []string) (DatasetExport, error) {
+	if validationFraction < 0 || validationFraction >= 1 {
+		return DatasetExport{}, fmt.Errorf("validation fraction must be in [0, 1), got %v", validationFraction)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return DatasetExport{}, fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+
+	exp := DatasetExport{
+		Train:      filepath.Join(dir, "train.csv"),
+		Validation: filepath.Join(dir, "validation.csv"),
+		ByLabel:    make(map[string]int),
+	}
+	var train, validation [][]string
+	for _, item := range q.Items() {
+		if item.Label == "" || item.Skipped {
+			continue
+		}
+		if len(labels) > 0 && !containsLabel(labels, item.Label) {
+			exp.Ignored++
+			continue
+		}
+		exp.ByLabel[item.Label]++
+		row := []string{item.Text, item.Label}
+		if validationSplit(item.ID, validationFraction) {
+			validation = append(validation, row)
+		} else {
+			train = append(train, row)
+		}
+	}
+	if len(train)+len(validation) == 0 {
+		return exp, errors.New("label queue has no labelled items to export")
+	}
+	exp.TrainCount, exp.ValidationCount = len(train), len(validation)
+
+	if err := writeDatasetCSV(exp.Train, train); err != nil {
+		return exp, err
+	}
+	if err := writeDatasetCSV(exp.Validation, validation); err != nil {
+		return exp, err
+	}
+	return exp, nil
+}
+
+func writeDatasetCSV(path string, rows [][]string) error {
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i][1] < rows[j][1] })
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write dataset: %w", err)
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"text", "label"})
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write dataset %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// validationSplit puts an item in the validation set when a hash of its ID
+// falls under fraction, so that it keeps its split as a dataset grows.
+func validationSplit(id string, fraction float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return float64(h.Sum32()%10000) < fraction*10000
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelRouter queues the predictions a LabelingConfig selects.
+type LabelRouter struct {
+	cfg   LabelingConfig
+	queue *LabelQueue
+}
+
+var (
+	labelQueuesMu sync.Mutex
+	labelQueues   = make(map[string]*LabelQueue)
+)
+
+// newLabelRouter returns the router for cfg. Analyzers opened with the same
+// queue path share one LabelQueue, which stays open for the life of the
+// process, so that a model swap doesn't re-add items the queue has seen.
+func newLabelRouter(cfg LabelingConfig) (*LabelRouter, error) {
+	labelQueuesMu.Lock()
+	defer labelQueuesMu.Unlock()
+	q, found := labelQueues[cfg.Queue]
+	if !found {
+		var err error
+		if q, err = OpenLabelQueue(cfg.Queue); err != nil {
+			return nil, err
+		}
+		labelQueues[cfg.Queue] = q
+	}
+	return &LabelRouter{cfg: cfg, queue: q}, nil
+}
+
+// Route queues text if res is less confident than MinConfidence or, with
+// Disagreement set, its ensemble members disagree.
+func (r *LabelRouter) Route(text string, res SentimentResult) {
+	var reasons []string
+	if res.Confidence < r.cfg.MinConfidence {
+		reasons = append(reasons, LabelReasonLowConfidence)
+	}
+	candidates := make(map[string]string)
+	if r.cfg.Disagreement {
+		for _, m := range res.Members {
+			if m.Label != res.Label {
+				candidates[m.Model] = m.Label
+			}
+		}
+		if len(candidates) > 0 {
+			reasons = append(reasons, LabelReasonDisagreement)
+		}
+	}
+	if len(reasons) > 0 {
+		r.add(text, res, reasons, candidates)
+	}
+}
+
+// RouteShadow queues text when a shadow model disagreed with the primary.
+func (r *LabelRouter) RouteShadow(text string, primary, shadow SentimentResult, shadowName string) {
+	if !r.cfg.Disagreement || primary.Label == shadow.Label {
+		return
+	}
+	r.add(text, primary, []string{LabelReasonShadow}, map[string]string{shadowName: shadow.Label})
+}
+
+func (r *LabelRouter) add(text string, res SentimentResult, reasons []string, candidates map[string]string) {
+	item := LabelItem{
+		Text:          text,
+		Reasons:       reasons,
+		Model:         res.Model,
+		ModelVersion:  res.ModelVersion,
+		Predicted:     res.Label,
+		Confidence:    res.Confidence,
+		Uncertainty:   res.Uncertainty,
+		Probabilities: res.Probabilities,
+	}
+	if len(candidates) > 0 {
+		item.Candidates = candidates
+	}
+	if _, err := r.queue.Add(item); err != nil {
+		fmt.Println("Error queueing text for labelling:", err)
+	}
+}
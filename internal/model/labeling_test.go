@@ -0,0 +1,141 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func queueLine(t *testing.T, item LabelItem) string {
+	t.Helper()
+	line, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line)
+}
+
+func TestLabelQueueReloadPrecedence(t *testing.T) {
+	pending := LabelItem{ID: "a", Text: "Infosys beats estimates", Reasons: []string{LabelReasonLowConfidence}}
+	merged := pending
+	merged.Reasons = []string{LabelReasonLowConfidence, LabelReasonShadow}
+	positive := pending
+	positive.Label, positive.Labeler = "positive", "asha"
+	negative := positive
+	negative.Label = "negative"
+	skipped := pending
+	skipped.Skipped, skipped.Labeler = true, "asha"
+
+	tests := []struct {
+		name  string
+		lines []LabelItem
+		want  LabelItem
+	}{
+		{"labeled after pending", []LabelItem{pending, positive}, positive},
+		{"pending never replaces labeled", []LabelItem{positive, merged}, positive},
+		{"pending never replaces skipped", []LabelItem{skipped, pending}, skipped},
+		{"relabel wins", []LabelItem{pending, positive, negative}, negative},
+		{"skip after label wins", []LabelItem{positive, skipped}, skipped},
+		{"label after skip wins", []LabelItem{skipped, negative}, negative},
+		{"later pending state wins", []LabelItem{pending, merged}, merged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "queue.jsonl")
+			var lines []string
+			for _, item := range tt.lines {
+				lines = append(lines, queueLine(t, item))
+			}
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			q, err := OpenLabelQueue(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer q.Close()
+			items := q.Items()
+			if len(items) != 1 {
+				t.Fatalf("%d items, want 1", len(items))
+			}
+			if !reflect.DeepEqual(items[0], tt.want) {
+				t.Errorf("item = %+v, want %+v", items[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelQueueReloadSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	first := LabelItem{ID: "a", Text: "Infosys beats estimates"}
+	second := LabelItem{ID: "b", Text: "Wipro misses estimates"}
+	labeled := first
+	labeled.Label = "positive"
+	// The last line was cut short by a crash.
+	raw := queueLine(t, first) + "\n\n" + queueLine(t, second) + "\n" + queueLine(t, labeled) + "\n" + `{"id":"c","text":"TCS`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q, err := OpenLabelQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	items := q.Items()
+	if len(items) != 2 || items[0].ID != "a" || items[1].ID != "b" {
+		t.Fatalf("items %+v, want a then b in first-added order", items)
+	}
+	if items[0].Label != "positive" {
+		t.Errorf("a not labeled: %+v", items[0])
+	}
+}
+
+// The bot and the labeler share the queue file from separate processes.
+// A text the bot queues again after it was labelled must stay labelled.
+func TestLabelQueueConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	bot, err := OpenLabelQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bot.Close()
+	item := LabelItem{Text: "Infosys beats estimates", Reasons: []string{LabelReasonLowConfidence}}
+	if _, err := bot.Add(item); err != nil {
+		t.Fatal(err)
+	}
+
+	labeler, err := OpenLabelQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer labeler.Close()
+	next, found := labeler.Next()
+	if !found {
+		t.Fatal("labeler sees no pending item")
+	}
+	if err := labeler.SetLabel(next.ID, "positive", "asha"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bot hasn't reloaded, so it merges a new reason into what it
+	// still thinks is a pending item and appends that.
+	item.Reasons = []string{LabelReasonShadow}
+	if changed, err := bot.Add(item); err != nil || !changed {
+		t.Fatalf("Add = %v, %v", changed, err)
+	}
+
+	for _, q := range []*LabelQueue{bot, labeler} {
+		if err := q.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		if s := q.Stats(); s.Total != 1 || s.Labeled != 1 || s.ByLabel["positive"] != 1 {
+			t.Errorf("stats after reload %+v, want one positive item", s)
+		}
+		if _, found := q.Next(); found {
+			t.Error("labelled item offered again")
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Finance word lists in the spirit of Loughran-McDonald: words that are
+// neutral in everyday English (liability, tax, crude) are deliberately absent,
+// and words with a specific financial polarity are included.
+var lexiconPositive = toSet(`
+	beat beats beating exceeded exceeds exceed outperform outperforms outperformed
+	gain gains gained rise rises rising rose rally rallies rallied surge surges surged
+	jump jumps jumped soar soars soared climb climbs climbed record profit profits
+	profitable profitability growth grow grows grew strong stronger strongest robust
+	improve improves improved improvement upgrade upgraded upgrades bullish boost boosts
+	boosted expand expands expanded expansion win wins won award awarded approval approved
+	dividend buyback positive optimistic optimism recovery recover recovers recovered
+	rebound rebounds rebounded success successful breakthrough momentum upbeat upside
+	attractive favorable favourable healthy resilient stable efficiency efficient
+	accelerate accelerated accelerating milestone leading highest higher exceptional
+	tailwind tailwinds inflows inflow overweight accumulate
+`)
+
+var lexiconNegative = toSet(`
+	miss misses missed fall falls fell falling drop drops dropped decline declines
+	declined declining plunge plunges plunged slump slumps slumped tumble tumbles tumbled
+	crash crashes crashed slide slides slid sink sinks sank loss losses lose loses lost
+	weak weaker weakest weakness downgrade downgraded downgrades bearish cut cuts
+	slash slashes slashed warn warns warned warning fraud scam probe investigation raid
+	default defaults defaulted bankruptcy bankrupt insolvency insolvent lawsuit litigation
+	penalty penalties fine fined ban banned halt halted suspend suspended suspension
+	resign resigns resigned resignation layoff layoffs delay delays delayed shortfall
+	negative pessimistic concern concerns worried worry worries risk risks risky volatile
+	volatility slowdown slow slower recession downturn deficit impairment writedown
+	write-off underperform underperforms underperformed disappointing disappoints
+	disappointed headwind headwinds outflows outflow lowest lower pressure pressured
+	underweight sell-off selloff dispute breach violation recall recalls downside
+`)
+
+var lexiconNegators = toSet(`not no never without neither nor none cannot fails fail failed hardly barely`)
+
+var lexiconIntensifiers = map[string]float64{
+	"very": 1.5, "highly": 1.5, "sharply": 1.6, "significantly": 1.5, "strongly": 1.5,
+	"massive": 1.7, "huge": 1.6, "steep": 1.6, "record": 1.3, "extremely": 1.7, "biggest": 1.5,
+	"slightly": 0.5, "marginally": 0.5, "modestly": 0.6, "somewhat": 0.6, "mildly": 0.6,
+}
+
+// LexiconAnalyzer is a dependency-free finance sentiment analyzer used when
+// the ONNX model is unavailable. It counts polarity words, flipping those
+// within three words after a negator and scaling those after an intensifier.
+type LexiconAnalyzer struct {
+	def ModelDefinition
+}
+
+// NewLexiconAnalyzer creates a lexicon analyzer. Results report def's name and version.
+func NewLexiconAnalyzer(def ModelDefinition) *LexiconAnalyzer {
+	def.Labels = []string{"negative", "neutral", "positive"}
+	if def.Name == "" {
+		def.Name = "lexicon"
+	}
+	return &LexiconAnalyzer{def: def}
+}
+
+// Analyze scores text from its polarity words.
+func (l *LexiconAnalyzer) Analyze(text string) (SentimentResult, error) {
+	start := time.Now()
+	if strings.TrimSpace(text) == "" {
+		return SentimentResult{}, ErrEmptyText
+	}
+
+	words := lexiconWords(text)
+	score := 0.0
+	negateFor := 0
+	intensity := 1.0
+	for _, w := range words {
+		if _, found := lexiconNegators[w]; found || strings.HasSuffix(w, "n't") {
+			negateFor = 3
+			continue
+		}
+		if v, found := lexiconIntensifiers[w]; found {
+			intensity = v
+			// "record" is also a positive word on its own
+			if _, pos := lexiconPositive[w]; !pos {
+				continue
+			}
+		}
+
+		polarity := 0.0
+		if _, found := lexiconPositive[w]; found {
+			polarity = 1
+		} else if _, found := lexiconNegative[w]; found {
+			polarity = -1
+		}
+		if polarity != 0 {
+			if negateFor > 0 {
+				polarity = -polarity
+			}
+			score += polarity * intensity
+			intensity = 1
+		}
+		if negateFor > 0 {
+			negateFor--
+		}
+	}
+
+	// Squash into (-1, 1) so that a few strong words dominate but long texts
+	// don't saturate, then turn the polarity into class probabilities.
+	norm := score / math.Sqrt(score*score+4)
+	logits := []float32{float32(-4 * norm), 0.5, float32(4 * norm)}
+	return newSentimentResult(l.def, softmax(logits), false, time.Since(start)), nil
+}
+
+// AnalyzeLong is the same as Analyze: the lexicon has no length limit.
+func (l *LexiconAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	return l.Analyze(text)
+}
+
+// AnalyzeBatch scores each text independently.
+func (l *LexiconAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := make([]BatchResult, len(texts))
+	for i, text := range texts {
+		results[i].SentimentResult, results[i].Err = l.Analyze(text)
+	}
+	return results
+}
+
+// Close is a no-op; the lexicon holds no native resources.
+func (l *LexiconAnalyzer) Close() error {
+	return nil
+}
+
+// lexiconWords lowercases text and splits it into words, keeping apostrophes
+// and hyphens so that "didn't" and "write-off" stay whole.
+func lexiconWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+}
+
+func toSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.Fields(words) {
+		set[w] = struct{}{}
+	}
+	return set
+}
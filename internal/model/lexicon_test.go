@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLexiconNegation(t *testing.T) {
+	lexicon := NewLexiconAnalyzer(ModelDefinition{})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"infosys beat estimates", "positive"},
+		{"infosys didn't beat estimates", "negative"},
+		{"infosys did not beat estimates", "negative"},
+		{"infosys never missed estimates", "positive"},
+		{"tcs failed to win the contract", "negative"},
+		// A negation reaches the next three words only.
+		{"not that the results were weak", "negative"},
+		{"not a loss for the first time", "positive"},
+		{"infosys board meets on friday", "neutral"},
+	}
+	for _, tt := range tests {
+		res, err := lexicon.Analyze(tt.text)
+		if err != nil {
+			t.Errorf("Analyze(%q): %v", tt.text, err)
+			continue
+		}
+		if res.Label != tt.want {
+			t.Errorf("Analyze(%q) = %s (%v), want %s", tt.text, res.Label, res.Probabilities, tt.want)
+		}
+	}
+}
+
+func TestLexiconEmptyInput(t *testing.T) {
+	lexicon := NewLexiconAnalyzer(ModelDefinition{})
+	for _, text := range []string{"", " \t\n"} {
+		if _, err := lexicon.Analyze(text); !errors.Is(err, ErrEmptyText) {
+			t.Errorf("Analyze(%q) error = %v, want ErrEmptyText", text, err)
+		}
+	}
+	results := lexicon.AnalyzeBatch([]string{"profit surged", ""})
+	if results[0].Err != nil || results[0].Label != "positive" {
+		t.Errorf("batch result 0 = %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrEmptyText) {
+		t.Errorf("batch result 1 error = %v, want ErrEmptyText", results[1].Err)
+	}
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jsonLog is an append-only JSON-lines file. Analyzers opened with the same
+// path share one jsonLog, so the old and new model of a swap, which are
+// both open until the old one's calls return, write through one descriptor
+// and never interleave partial lines. The file is closed with its last user.
+type jsonLog struct {
+	path string
+	refs int // guarded by jsonLogsMu
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+var (
+	jsonLogsMu sync.Mutex
+	jsonLogs   = make(map[string]*jsonLog)
+)
+
+// openJSONLog returns the log at path, creating the file and its directory
+// if needed. Each call must be matched by a Close.
+func openJSONLog(path string) (*jsonLog, error) {
+	path = filepath.Clean(path)
+	jsonLogsMu.Lock()
+	defer jsonLogsMu.Unlock()
+	if l, found := jsonLogs[path]; found {
+		l.refs++
+		return l, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &jsonLog{path: path, refs: 1, file: f}
+	jsonLogs[path] = l
+	return l, nil
+}
+
+// Write appends v as one line.
+func (l *jsonLog) Write(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("log %s is closed", l.path)
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close releases this user of the log and closes the file if it was the last.
+func (l *jsonLog) Close() error {
+	jsonLogsMu.Lock()
+	l.refs--
+	last := l.refs == 0
+	if last {
+		delete(jsonLogs, l.path)
+	}
+	jsonLogsMu.Unlock()
+	if !last {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
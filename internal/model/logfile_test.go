@@ -0,0 +1,80 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONLogSharedPerPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "shadow.jsonl")
+	old, err := openJSONLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The new generation of a swap opens the same path, relative or not.
+	cur, err := openJSONLog(filepath.Join(filepath.Dir(path), ".", "shadow.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old != cur {
+		t.Fatal("second open of the same path returned a new log")
+	}
+
+	old.Write(map[string]string{"model": "old"})
+	old.Close()
+	if err := cur.Write(map[string]string{"model": "new"}); err != nil {
+		t.Fatalf("write after the other user closed: %v", err)
+	}
+	cur.Close()
+	if err := cur.Write(map[string]string{"model": "late"}); err == nil {
+		t.Error("write after the last close succeeded")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"model":"old"}` + "\n" + `{"model":"new"}` + "\n"
+	if string(raw) != want {
+		t.Errorf("log = %q, want %q", raw, want)
+	}
+	if _, found := jsonLogs[path]; found {
+		t.Error("closed log still registered")
+	}
+}
+
+func TestDriftDetectorSharedPerVersion(t *testing.T) {
+	cfg := DriftConfig{Window: 10, Log: filepath.Join(t.TempDir(), "drift.jsonl")}
+	def := ModelDefinition{Name: "finbert", Version: "v1", Labels: []string{"negative", "neutral", "positive"}}
+	a, err := openDriftDetector(def, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := openDriftDetector(def, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	def.Version = "v2"
+	c, err := openDriftDetector(def, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b || a == c {
+		t.Fatal("detectors not shared by version")
+	}
+	if a.log != c.log {
+		t.Error("versions write the drift log through separate files")
+	}
+
+	a.Observe(SentimentResult{Label: "positive", Confidence: 0.9, Score: 0.8})
+	a.Close()
+	if b.log == nil || b.Status().Reference != 1 {
+		t.Error("closing one user closed the shared detector")
+	}
+	b.Close()
+	c.Close()
+	if b.log != nil || len(driftDetectors) != 0 {
+		t.Error("detector not closed by its last user")
+	}
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	predictionCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_predictions_total",
+			Help: "Sentiment predictions served, by model and predicted label",
+		},
+		[]string{"model", "label"},
+	)
+	predictionConfidence = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_prediction_confidence",
+			Help:    "Probability of the predicted label",
+			Buckets: prometheus.LinearBuckets(0.35, 0.05, 14), // 0.35 to 1.0
+		},
+		[]string{"model", "label"},
+	)
+	inferenceDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_inference_duration_seconds",
+			Help:    "Duration of sentiment calls, including cache lookups, by mode (short, long, batch)",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms to ~4s
+		},
+		[]string{"model", "mode"},
+	)
+	inferenceErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_inference_errors_total",
+			Help: "Texts that could not be scored",
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(predictionCount, predictionConfidence, inferenceDuration, inferenceErrors)
+}
+
+// MonitoredAnalyzer records the predictions of the wrapped analyzer in
+// Prometheus, feeds them to a DriftDetector and routes uncertain ones to the
+// labelling queue when those are configured. It is the outermost layer of
+// OpenSentimentAnalyzer, so it sees every result served, cached or not.
+type MonitoredAnalyzer struct {
+	inner  SentimentAnalyzer
+	def    ModelDefinition
+	drift  *DriftDetector // nil when drift detection is off
+	labels *LabelRouter   // nil when labelling is off
+}
+
+// NewMonitoredAnalyzer wraps inner, the analyzer for def. drift and labels
+// may be nil.
+func NewMonitoredAnalyzer(inner SentimentAnalyzer, def ModelDefinition, drift *DriftDetector, labels *LabelRouter) *MonitoredAnalyzer {
+	return &MonitoredAnalyzer{inner: inner, def: def, drift: drift, labels: labels}
+}
+
+// Analyze scores text with the wrapped analyzer.
+func (m *MonitoredAnalyzer) Analyze(text string) (SentimentResult, error) {
+	start := time.Now()
+	res, err := m.inner.Analyze(text)
+	inferenceDuration.WithLabelValues(m.def.Name, "short").Observe(time.Since(start).Seconds())
+	m.observe(text, res, err)
+	return res, err
+}
+
+// AnalyzeLong scores a long text with the wrapped analyzer.
+func (m *MonitoredAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	start := time.Now()
+	res, err := m.inner.AnalyzeLong(text)
+	inferenceDuration.WithLabelValues(m.def.Name, "long").Observe(time.Since(start).Seconds())
+	m.observe(text, res, err)
+	return res, err
+}
+
+// AnalyzeBatch scores texts with the wrapped analyzer. The duration of the
+// whole batch is recorded once.
+func (m *MonitoredAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	start := time.Now()
+	results := m.inner.AnalyzeBatch(texts)
+	inferenceDuration.WithLabelValues(m.def.Name, "batch").Observe(time.Since(start).Seconds())
+	for i, res := range results {
+		m.observe(texts[i], res.SentimentResult, res.Err)
+	}
+	return results
+}
+
+// Explain explains with the wrapped analyzer. Explanations are not counted
+// as predictions.
+func (m *MonitoredAnalyzer) Explain(text string) (Explanation, error) {
+	return Explain(m.inner, text)
+}
+
+// Drift returns the analyzer's drift detector, or nil if drift detection is off.
+func (m *MonitoredAnalyzer) Drift() *DriftDetector {
+	return m.drift
+}
+
+// Close closes the wrapped analyzer and the drift detector.
+func (m *MonitoredAnalyzer) Close() error {
+	err := m.inner.Close()
+	if m.drift != nil {
+		m.drift.Close()
+	}
+	return err
+}
+
+func (m *MonitoredAnalyzer) observe(text string, res SentimentResult, err error) {
+	if err != nil {
+		inferenceErrors.WithLabelValues(m.def.Name).Inc()
+		return
+	}
+	predictionCount.WithLabelValues(m.def.Name, res.Label).Inc()
+	predictionConfidence.WithLabelValues(m.def.Name, res.Label).Observe(float64(res.Confidence))
+	if m.drift != nil {
+		m.drift.Observe(res)
+	}
+	if m.labels != nil {
+		m.labels.Route(text, res)
+	}
+}
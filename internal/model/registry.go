@@ -0,0 +1,278 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRegistryPath is where registered model versions are recorded.
+const DefaultRegistryPath = "models/registry.yaml"
+
+var (
+	// ErrLFSPointer means a model file is the small text pointer Git LFS
+	// leaves behind when the real file wasn't fetched.
+	ErrLFSPointer = errors.New("file is a Git LFS pointer, not the model itself (run git lfs pull)")
+	// ErrChecksumMismatch means a model file isn't the registered version.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/"
+
+// ModelVersion is one registered version of a model file.
+type ModelVersion struct {
+	Version      string             `yaml:"version"`
+	Path         string             `yaml:"path"`
+	SHA256       string             `yaml:"sha256"`
+	Size         int64              `yaml:"size"`
+	Calibration  string             `yaml:"calibration,omitempty"` // fitted for this version's logits
+	Source       string             `yaml:"source,omitempty"` // where the weights came from and how they were exported
+	Notes        string             `yaml:"notes,omitempty"`
+	RegisteredAt time.Time          `yaml:"registered_at,omitempty"`
+	Metrics      map[string]float64 `yaml:"metrics,omitempty"` // e.g. macro_f1 from cmd/sentimenteval
+}
+
+// ModelRegistry records the versions of each model with their checksums, so
+// that a deployment can verify it runs exactly the file that was evaluated.
+type ModelRegistry struct {
+	path   string
+	Models map[string][]ModelVersion `yaml:"models"`
+}
+
+// LoadModelRegistry reads the registry at path. A missing file is an empty registry.
+func LoadModelRegistry(path string) (*ModelRegistry, error) {
+	r := &ModelRegistry{path: path, Models: make(map[string][]ModelVersion)}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model registry: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, r); err != nil {
+		return nil, fmt.Errorf("failed to parse model registry %s: %w", path, err)
+	}
+	if r.Models == nil {
+		r.Models = make(map[string][]ModelVersion)
+	}
+	return r, nil
+}
+
+// Save writes the registry back to the file it was loaded from.
+func (r *ModelRegistry) Save() error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	header := "# Registered model versions, maintained with cmd/modelctl. The sha256 and size\n" +
+		"# of every file are checked before a model is loaded.\n"
+	if err := os.WriteFile(r.path, append([]byte(header), raw...), 0o644); err != nil {
+		return fmt.Errorf("failed to write model registry: %w", err)
+	}
+	return nil
+}
+
+// Version returns the registered version of a model.
+func (r *ModelRegistry) Version(name, version string) (ModelVersion, bool) {
+	for _, v := range r.Models[name] {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return ModelVersion{}, false
+}
+
+// Latest returns the most recently registered version of a model.
+func (r *ModelRegistry) Latest(name string) (ModelVersion, bool) {
+	versions := r.Models[name]
+	if len(versions) == 0 {
+		return ModelVersion{}, false
+	}
+	latest := versions[0]
+	for _, v := range versions[1:] {
+		if !v.RegisteredAt.Before(latest.RegisteredAt) {
+			latest = v
+		}
+	}
+	return latest, true
+}
+
+// Register checksums the file at v.Path and records it as v.Version of the
+// model, replacing an earlier registration of the same version.
+func (r *ModelRegistry) Register(name string, v ModelVersion) (ModelVersion, error) {
+	if v.Version == "" {
+		return v, errors.New("version is required")
+	}
+	if err := checkLFSPointer(v.Path); err != nil {
+		return v, err
+	}
+	sum, size, err := fileSHA256(v.Path)
+	if err != nil {
+		return v, err
+	}
+	v.SHA256, v.Size = sum, size
+	if v.RegisteredAt.IsZero() {
+		v.RegisteredAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	versions := r.Models[name]
+	for i := range versions {
+		if versions[i].Version == v.Version {
+			versions[i] = v
+			return v, nil
+		}
+	}
+	r.Models[name] = append(versions, v)
+	sort.SliceStable(r.Models[name], func(i, j int) bool {
+		return r.Models[name][i].RegisteredAt.Before(r.Models[name][j].RegisteredAt)
+	})
+	return v, nil
+}
+
+// Apply returns def pointed at a registered version of its model, with
+// that version's calibration. A calibration is fitted to one version's
+// logits, so def's own is kept only when def already is that version and
+// the registry names none; otherwise the version is served uncalibrated,
+// with a warning.
+func (r *ModelRegistry) Apply(def ModelDefinition, version string) (ModelDefinition, error) {
+	v, found := r.Version(def.Name, version)
+	if !found {
+		return def, fmt.Errorf("model %q has no registered version %q", def.Name, version)
+	}
+	if v.Calibration != "" || v.Version != def.Version {
+		def.CalibrationPath = v.Calibration
+	}
+	def.Calibration = nil
+	def.Version = v.Version
+	def.Path = v.Path
+	def.SHA256 = v.SHA256
+	def.Size = v.Size
+	if err := def.loadCalibration(); err != nil {
+		return def, fmt.Errorf("model %q version %q: %w", def.Name, v.Version, err)
+	}
+	if def.Calibration == nil {
+		fmt.Printf("Model %s %s has no calibration, its probabilities are uncalibrated\n", def.Name, v.Version)
+	}
+	return def, nil
+}
+
+// Verify checks the file of every registered version that is present on disk.
+// Missing files are skipped: old versions are usually deleted.
+func (r *ModelRegistry) Verify() error {
+	var errs []error
+	names := make([]string, 0, len(r.Models))
+	for name := range r.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range r.Models[name] {
+			if _, err := os.Stat(v.Path); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err := VerifyModelFile(v.Path, v.SHA256, v.Size); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", name, v.Version, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyModelFile checks that path holds a real ONNX model rather than a Git
+// LFS pointer or a truncated download, and, when they are given, that its
+// size and SHA-256 match. Hashing reads the whole file.
+func VerifyModelFile(path, wantSHA256 string, wantSize int64) error {
+	if err := checkLFSPointer(path); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open model: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if wantSize > 0 && info.Size() != wantSize {
+		return fmt.Errorf("%s is %d bytes, expected %d: incomplete or wrong file", path, info.Size(), wantSize)
+	}
+	// An ONNX file is a ModelProto whose first field is ir_version (field 1,
+	// varint), so it starts with the tag byte 0x08.
+	head := make([]byte, 1)
+	if _, err := io.ReadFull(f, head); err != nil || head[0] != 0x08 {
+		return fmt.Errorf("%s does not look like an ONNX model", path)
+	}
+
+	if wantSHA256 == "" {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read model: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, wantSHA256) {
+		return fmt.Errorf("%s: %w: sha256 is %s, expected %s", path, ErrChecksumMismatch, got, wantSHA256)
+	}
+	return nil
+}
+
+// checkLFSPointer returns ErrLFSPointer, with the object the pointer refers
+// to, if path is a Git LFS pointer file.
+func checkLFSPointer(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if !bytes.HasPrefix(head, []byte(lfsPointerPrefix)) {
+		return nil
+	}
+
+	var oid string
+	var size int64
+	sc := bufio.NewScanner(bytes.NewReader(head))
+	for sc.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+		switch key {
+		case "oid":
+			oid = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			size, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return fmt.Errorf("%s: %w; it points to sha256 %s (%d bytes)", path, ErrLFSPointer, oid, size)
+}
+
+func fileSHA256(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fakeONNX starts with the tag byte of a ModelProto's ir_version.
+var fakeONNX = []byte{0x08, 0x07, 0x12, 0x04, 't', 'e', 's', 't'}
+
+const lfsPointer = "version https://git-lfs.github.com/spec/v1\n" +
+	"oid sha256:b4e653c4df9bbc58f0ee548c944e296d8ffd1d276a99abfb66e8328fe5b05f4e\n" +
+	"size 438051187\n"
+
+func TestVerifyModelFile(t *testing.T) {
+	dir := t.TempDir()
+	model := writeFile(t, dir, "model.onnx", fakeONNX)
+	sum := sha256.Sum256(fakeONNX)
+	goodSHA := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name    string
+		path    string
+		sha     string
+		size    int64
+		wantErr error // nil for no error, errAny for an error of no particular kind
+	}{
+		{"no checks", model, "", 0, nil},
+		{"matching sha and size", model, goodSHA, int64(len(fakeONNX)), nil},
+		{"sha in upper case", model, hexUpper(goodSHA), 0, nil},
+		{"sha mismatch", model, hex.EncodeToString(make([]byte, 32)), 0, ErrChecksumMismatch},
+		{"size mismatch", model, goodSHA, 3, errAny},
+		{"lfs pointer", writeFile(t, dir, "pointer.onnx", []byte(lfsPointer)), "", 0, ErrLFSPointer},
+		{"not onnx", writeFile(t, dir, "model.json", []byte(`{"model": 1}`)), "", 0, errAny},
+		{"missing", filepath.Join(dir, "missing.onnx"), "", 0, errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyModelFile(tt.path, tt.sha, tt.size)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != nil && err == nil:
+				t.Error("expected an error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Errorf("error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("any error")
+
+func hexUpper(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'a' && c <= 'f' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
+
+func TestModelRegistryRegister(t *testing.T) {
+	dir := t.TempDir()
+	r, err := LoadModelRegistry(filepath.Join(dir, "registry.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register("finbert", ModelVersion{Version: "1.0", Path: writeFile(t, dir, "pointer.onnx", []byte(lfsPointer))}); !errors.Is(err, ErrLFSPointer) {
+		t.Fatalf("registering an LFS pointer: %v", err)
+	}
+
+	path := writeFile(t, dir, "model.onnx", fakeONNX)
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := r.Register("finbert", ModelVersion{Version: "1.1", Path: path, RegisteredAt: t0.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Register("finbert", ModelVersion{Version: "1.0", Path: path, RegisteredAt: t0, Calibration: "configs/calibration/finbert-1.0.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(fakeONNX)
+	if v.SHA256 != hex.EncodeToString(sum[:]) || v.Size != int64(len(fakeONNX)) {
+		t.Errorf("registered %+v", v)
+	}
+	if latest, _ := r.Latest("finbert"); latest.Version != "1.1" {
+		t.Errorf("latest is %s, want 1.1", latest.Version)
+	}
+
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadModelRegistry(filepath.Join(dir, "registry.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, found := loaded.Version("finbert", "1.0")
+	if !found || got.SHA256 != v.SHA256 || got.Calibration != v.Calibration {
+		t.Errorf("reloaded %+v, want %+v", got, v)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestModelRegistryApply(t *testing.T) {
+	dir := t.TempDir()
+	labels := []string{"negative", "neutral", "positive"}
+	saveCalibration := func(name string, temperature float64, labels []string) string {
+		path := filepath.Join(dir, name)
+		if err := (&Calibration{Method: CalibrateTemperature, Labels: labels, Temperature: temperature}).Save(path); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	configured := saveCalibration("v1.json", 2, labels)
+	r := &ModelRegistry{Models: map[string][]ModelVersion{"finbert": {
+		{Version: "v1", Path: "models/v1.onnx"},
+		{Version: "v2", Path: "models/v2.onnx", SHA256: "abc", Size: 10, Calibration: saveCalibration("v2.json", 0.5, labels)},
+		{Version: "v3", Path: "models/v3.onnx"},
+		{Version: "v4", Path: "models/v4.onnx", Calibration: saveCalibration("v4.json", 1.5, []string{"neg", "pos"})},
+		{Version: "v5", Path: "models/v5.onnx", Calibration: filepath.Join(dir, "missing.json")},
+	}}}
+	def := ModelDefinition{Name: "finbert", Version: "v1", Path: "models/v1.onnx", Labels: labels, CalibrationPath: configured}
+	if err := def.loadCalibration(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version     string
+		temperature float64 // 0 for uncalibrated
+		wantErr     bool
+	}{
+		{"v1", 2, false},   // the configured version keeps its own calibration
+		{"v2", 0.5, false}, // the registry's calibration of the version
+		{"v3", 0, false},   // never the old version's
+		{"v4", 0, true},    // fitted for other labels
+		{"v5", 0, false},   // not fitted yet
+		{"v9", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := r.Apply(def, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Version != tt.version || got.Path != "models/"+tt.version+".onnx" {
+				t.Errorf("applied %s at %s", got.Version, got.Path)
+			}
+			switch {
+			case tt.temperature == 0 && got.Calibration != nil:
+				t.Errorf("calibration %+v carried over", got.Calibration)
+			case tt.temperature != 0 && (got.Calibration == nil || got.Calibration.Temperature != tt.temperature):
+				t.Errorf("calibration %+v, want temperature %v", got.Calibration, tt.temperature)
+			}
+		})
+	}
+	if def.Calibration.Temperature != 2 {
+		t.Error("Apply modified the definition it was given")
+	}
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// SentimentResult is the full prediction for one text.
+type SentimentResult struct {
+	Label         string             `json:"label"`
+	Confidence    float32            `json:"confidence"`    // probability of Label
+	Probabilities map[string]float32 `json:"probabilities"` // every class, summing to 1
+	Score         float32            `json:"score"`         // p(positive) - p(negative), in [-1, 1]
+	Entropy       float64            `json:"entropy"`       // in nats
+	Uncertainty   float64            `json:"uncertainty"`   // entropy normalized to [0, 1]
+
+	Model        string        `json:"model"`
+	ModelVersion string        `json:"model_version,omitempty"`
+	Truncated    bool          `json:"truncated"`  // input was longer than the model's max length
+	Latency      time.Duration `json:"latency_ns"` // of the original computation for cached results
+	Cached       bool          `json:"cached,omitempty"`
+	Calibrated   bool          `json:"calibrated,omitempty"` // probabilities went through the model's calibration
+
+	// Set by AnalyzeLong only.
+	Aggregation string            `json:"aggregation,omitempty"`
+	Chunks      []SentimentResult `json:"chunks,omitempty"`
+
+	// Set by ensembles: the results of the members that were combined.
+	Members []SentimentResult `json:"members,omitempty"`
+}
+
+// Probability returns the probability of label, or 0 if the model doesn't have it.
+func (r SentimentResult) Probability(label string) float32 {
+	return r.Probabilities[label]
+}
+
+// newSentimentResult builds a result from raw class probabilities ordered
+// like def.Labels, calibrating them first if the model has a calibration.
+func newSentimentResult(def ModelDefinition, probs []float32, truncated bool, latency time.Duration) SentimentResult {
+	calibrated := def.Calibration != nil
+	if calibrated {
+		probs = def.Calibration.Apply(probs)
+	}
+	idx := argmax(probs)
+	r := SentimentResult{
+		Label:         def.Labels[idx],
+		Confidence:    probs[idx],
+		Probabilities: make(map[string]float32, len(probs)),
+		Model:         def.Name,
+		ModelVersion:  def.Version,
+		Truncated:     truncated,
+		Latency:       latency,
+		Calibrated:    calibrated,
+	}
+	for i, p := range probs {
+		r.Probabilities[def.Labels[i]] = p
+		if p > 0 {
+			r.Entropy -= float64(p) * math.Log(float64(p))
+		}
+	}
+	if len(probs) > 1 {
+		r.Uncertainty = r.Entropy / math.Log(float64(len(probs)))
+	}
+	r.Score = r.Probabilities["positive"] - r.Probabilities["negative"]
+	return r
+}
+
+func argmax(v []float32) int {
+	idx := 0
+	for i := 1; i < len(v); i++ {
+		if v[i] > v[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewSentimentResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		logits []float32
+		cal    *Calibration
+		label  string
+	}{
+		{"finbert order", []string{"positive", "negative", "neutral"}, []float32{2, 0, 1}, nil, "positive"},
+		{"sorted order", []string{"negative", "neutral", "positive"}, []float32{2, 0, 1}, nil, "negative"},
+		{"binary", []string{"negative", "positive"}, []float32{-1, 1}, nil, "positive"},
+		{"uniform", []string{"negative", "neutral", "positive"}, []float32{0, 0, 0}, nil, "negative"},
+		{"extreme logits", []string{"negative", "neutral", "positive"}, []float32{-1000, 0, 1000}, nil, "positive"},
+		{"calibrated", []string{"negative", "neutral", "positive"}, []float32{0, 1, 3},
+			&Calibration{Method: CalibrateTemperature, Labels: []string{"negative", "neutral", "positive"}, Temperature: 2}, "positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := ModelDefinition{Name: "finbert", Version: "v1", Labels: tt.labels, Calibration: tt.cal}
+			r := newSentimentResult(def, softmax(tt.logits), true, time.Millisecond)
+
+			if r.Label != tt.label || r.Confidence != r.Probability(tt.label) {
+				t.Errorf("label %q confidence %g, want %q with p=%g", r.Label, r.Confidence, tt.label, r.Probability(tt.label))
+			}
+			if len(r.Probabilities) != len(tt.labels) {
+				t.Errorf("probabilities = %v, want one per label", r.Probabilities)
+			}
+			var sum float64
+			for label, p := range r.Probabilities {
+				if p < 0 || p > 1 || math.IsNaN(float64(p)) {
+					t.Errorf("p(%s) = %g", label, p)
+				}
+				sum += float64(p)
+			}
+			if math.Abs(sum-1) > 1e-5 {
+				t.Errorf("probabilities sum to %g, want 1", sum)
+			}
+			if want := r.Probability("positive") - r.Probability("negative"); r.Score != want || r.Score < -1 || r.Score > 1 {
+				t.Errorf("score = %g, want p(positive) - p(negative) = %g", r.Score, want)
+			}
+			if r.Uncertainty < 0 || r.Uncertainty > 1+1e-6 {
+				t.Errorf("uncertainty = %g, want it in [0, 1]", r.Uncertainty)
+			}
+			if r.Calibrated != (tt.cal != nil) || r.Model != "finbert" || r.ModelVersion != "v1" || !r.Truncated || r.Latency != time.Millisecond {
+				t.Errorf("metadata = calibrated %v model %q version %q truncated %v latency %v", r.Calibrated, r.Model, r.ModelVersion, r.Truncated, r.Latency)
+			}
+		})
+	}
+}
+
+func TestNewSentimentResultScore(t *testing.T) {
+	def := ModelDefinition{Labels: []string{"positive", "negative", "neutral"}}
+	r := newSentimentResult(def, []float32{0.7, 0.2, 0.1}, false, 0)
+	if math.Abs(float64(r.Score)-0.5) > 1e-6 {
+		t.Errorf("score = %g, want 0.5", r.Score)
+	}
+	// A uniform distribution has the maximum entropy.
+	r = newSentimentResult(def, []float32{1. / 3, 1. / 3, 1. / 3}, false, 0)
+	if r.Score != 0 || math.Abs(r.Uncertainty-1) > 1e-6 || math.Abs(r.Entropy-math.Log(3)) > 1e-6 {
+		t.Errorf("uniform: score %g entropy %g uncertainty %g, want 0 ln(3) 1", r.Score, r.Entropy, r.Uncertainty)
+	}
+	// Models without a positive/negative pair score 0.
+	r = newSentimentResult(ModelDefinition{Labels: []string{"spam", "ham"}}, []float32{0.9, 0.1}, false, 0)
+	if r.Score != 0 {
+		t.Errorf("spam/ham score = %g, want 0", r.Score)
+	}
+}
@@ -0,0 +1,282 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	onnxruntime "github.com/yalue/onnxruntime_go"
+)
+
+var (
+	onnxRunDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "onnx_run_duration_seconds",
+			Help:    "Duration of ONNX model runs, by model",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms to ~4s
+		},
+		[]string{"model"},
+	)
+	onnxRunTexts = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "onnx_run_texts",
+			Help:    "Texts per ONNX model run, by model",
+			Buckets: []float64{1, 2, 4, 8, 16, 32, 64},
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(onnxRunDuration, onnxRunTexts)
+}
+
+// onnxRunner owns the session pool of one BERT-style classifier: a pool of
+// ONNX sessions, each with its own preallocated tensors, shared by
+// concurrent callers. It returns raw logits; turning them into predictions
+// is up to the Analyzer or TextClassifier that owns it.
+type onnxRunner struct {
+	def       ModelDefinition
+	closedErr error // returned by run after close
+
+	// run executes one model run over inputs and returns a copy of each
+	// row of logits. It is runSessions, or a stub in tests.
+	run func(inputs []TokenizedOutput) ([][]float32, error)
+
+	mu     sync.RWMutex // held for reading while a session is in use
+	closed bool
+	slots  []*sessionSlot
+	pool   chan *sessionSlot
+}
+
+// sessionSlot is one ONNX session with preallocated tensors for single
+// texts and for full batches. The exported model has a dynamic batch axis,
+// so the same session runs both.
+type sessionSlot struct {
+	session *onnxruntime.DynamicAdvancedSession
+	single  *batchTensors
+	batch   *batchTensors
+}
+
+// batchTensors holds the input and output tensors for one batch size.
+type batchTensors struct {
+	size          int
+	inputIDs      *onnxruntime.Tensor[int64]
+	attentionMask *onnxruntime.Tensor[int64]
+	logits        *onnxruntime.Tensor[float32]
+}
+
+// loadONNXModel checks the model file and vocabulary of def, initializes
+// ONNX Runtime, checks the model against def and loads its tokenizer. def
+// must have its defaults applied and be valid.
+func loadONNXModel(def ModelDefinition) (*WordPieceTokenizer, error) {
+	// Catch LFS pointers and corrupt downloads here, with a clear message,
+	// rather than as an opaque ONNX Runtime error.
+	if err := VerifyModelFile(def.Path, def.SHA256, def.Size); err != nil {
+		return nil, fmt.Errorf("model %q: %w", def.Name, err)
+	}
+	if err := checkLFSPointer(def.Vocab); err != nil {
+		return nil, fmt.Errorf("model %q: %w", def.Name, err)
+	}
+	if err := initializeORT(); err != nil {
+		return nil, err
+	}
+	if err := def.VerifyONNX(); err != nil {
+		return nil, fmt.Errorf("model %q does not match its definition: %w", def.Name, err)
+	}
+
+	tok, err := LoadWordPieceTokenizer(def.Vocab, def.MaxLength)
+	if err != nil {
+		return nil, fmt.Errorf("error loading tokenizer: %w", err)
+	}
+	return tok, nil
+}
+
+// newONNXRunner creates def.PoolSize sessions of the model. ONNX Runtime
+// must be initialized.
+func newONNXRunner(def ModelDefinition, closedErr error) (*onnxRunner, error) {
+	r := &onnxRunner{
+		def:       def,
+		closedErr: closedErr,
+		pool:      make(chan *sessionSlot, def.PoolSize),
+	}
+	r.run = r.runSessions
+	for i := 0; i < def.PoolSize; i++ {
+		slot, err := newSessionSlot(def)
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		r.slots = append(r.slots, slot)
+		r.pool <- slot
+	}
+	return r, nil
+}
+
+func newSessionSlot(def ModelDefinition) (*sessionSlot, error) {
+	slot := &sessionSlot{}
+
+	var err error
+	if slot.single, err = newBatchTensors(1, def.MaxLength, len(def.Labels)); err != nil {
+		return nil, err
+	}
+	if slot.batch, err = newBatchTensors(def.BatchSize, def.MaxLength, len(def.Labels)); err != nil {
+		slot.destroy()
+		return nil, err
+	}
+
+	slot.session, err = onnxruntime.NewDynamicAdvancedSession(
+		def.Path,
+		[]string{def.InputIDsName, def.AttentionMaskName},
+		[]string{def.OutputName},
+		nil,
+	)
+	if err != nil {
+		slot.destroy()
+		return nil, fmt.Errorf("failed to create ONNX session: %w", err)
+	}
+	return slot, nil
+}
+
+func newBatchTensors(size, sequenceLength, classes int) (*batchTensors, error) {
+	shape := onnxruntime.NewShape(int64(size), int64(sequenceLength))
+	t := &batchTensors{size: size}
+
+	var err error
+	if t.inputIDs, err = onnxruntime.NewEmptyTensor[int64](shape); err != nil {
+		return nil, fmt.Errorf("input_ids tensor error: %w", err)
+	}
+	if t.attentionMask, err = onnxruntime.NewEmptyTensor[int64](shape); err != nil {
+		t.destroy()
+		return nil, fmt.Errorf("attention_mask tensor error: %w", err)
+	}
+	// [batch_size, num_classes]
+	if t.logits, err = onnxruntime.NewEmptyTensor[float32](onnxruntime.NewShape(int64(size), int64(classes))); err != nil {
+		t.destroy()
+		return nil, fmt.Errorf("failed to create output tensor: %w", err)
+	}
+	return t, nil
+}
+
+func (t *batchTensors) destroy() {
+	if t.inputIDs != nil {
+		t.inputIDs.Destroy()
+	}
+	if t.attentionMask != nil {
+		t.attentionMask.Destroy()
+	}
+	if t.logits != nil {
+		t.logits.Destroy()
+	}
+}
+
+func (s *sessionSlot) destroy() {
+	if s.session != nil {
+		s.session.Destroy()
+	}
+	if s.single != nil {
+		s.single.destroy()
+	}
+	if s.batch != nil {
+		s.batch.destroy()
+	}
+}
+
+// runSessions runs inputs on a session from the pool.
+func (r *onnxRunner) runSessions(inputs []TokenizedOutput) ([][]float32, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return nil, r.closedErr
+	}
+
+	slot := <-r.pool
+	defer func() { r.pool <- slot }()
+
+	tensors := slot.single
+	switch n := len(inputs); {
+	case n == slot.batch.size:
+		tensors = slot.batch
+	case n > 1:
+		// Partial final batch: use tensors of the exact size for this run.
+		var err error
+		if tensors, err = newBatchTensors(n, r.def.MaxLength, len(r.def.Labels)); err != nil {
+			return nil, err
+		}
+		defer tensors.destroy()
+	}
+
+	ids := tensors.inputIDs.GetData()
+	mask := tensors.attentionMask.GetData()
+	seq := r.def.MaxLength
+	for i, in := range inputs {
+		copy(ids[i*seq:(i+1)*seq], in.InputIDs)
+		copy(mask[i*seq:(i+1)*seq], in.AttentionMask)
+	}
+
+	start := time.Now()
+	err := slot.session.Run(
+		[]onnxruntime.Value{tensors.inputIDs, tensors.attentionMask},
+		[]onnxruntime.Value{tensors.logits},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ONNX inference run failed: %w", err)
+	}
+	onnxRunDuration.WithLabelValues(r.def.Name).Observe(time.Since(start).Seconds())
+	onnxRunTexts.WithLabelValues(r.def.Name).Observe(float64(len(inputs)))
+
+	// Copy the logits out: the tensor is reused by the next caller of this slot.
+	data := tensors.logits.GetData()
+	classes := len(r.def.Labels)
+	if len(data) != len(inputs)*classes {
+		return nil, fmt.Errorf("unexpected logits length: got %d, expected %d", len(data), len(inputs)*classes)
+	}
+	out := make([][]float32, len(inputs))
+	for i := range out {
+		out[i] = append([]float32(nil), data[i*classes:(i+1)*classes]...)
+	}
+	return out, nil
+}
+
+// runBatches tokenizes texts and runs them in model runs of up to BatchSize
+// texts. done is called once per run with the indexes of its texts, their
+// encodings and logits (nil on error), and the duration of the run. Empty
+// texts are reported through one call with an error and no run.
+func (r *onnxRunner) runBatches(tok *WordPieceTokenizer, texts []string, done func(idx []int, inputs []TokenizedOutput, logits [][]float32, latency time.Duration, err error)) {
+	var idx, empty []int
+	var inputs []TokenizedOutput
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			empty = append(empty, i)
+			continue
+		}
+		idx = append(idx, i)
+		inputs = append(inputs, tok.Encode(text))
+	}
+	if len(empty) > 0 {
+		done(empty, nil, nil, 0, ErrEmptyText)
+	}
+
+	for start := 0; start < len(inputs); start += r.def.BatchSize {
+		end := min(start+r.def.BatchSize, len(inputs))
+		runStart := time.Now()
+		logits, err := r.run(inputs[start:end])
+		done(idx[start:end], inputs[start:end], logits, time.Since(runStart), err)
+	}
+}
+
+// close waits for in-flight runs to finish and destroys all sessions and tensors.
+func (r *onnxRunner) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for _, slot := range r.slots {
+		slot.destroy()
+	}
+	r.slots = nil
+}
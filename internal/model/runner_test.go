@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+var benchmarkTexts = []string{
+	"Infosys shares rose 5% after Q2 results beat estimates",
+	"HDFC Bank net profit jumps 18% year on year, asset quality improves",
+	"Tata Motors recalls 10,000 electric vehicles over battery fire risk",
+	"SEBI bars promoters from the securities market for two years",
+	"Reliance Industries to raise Rs 20,000 crore through a rights issue",
+	"Adani Ports cargo volumes flat in September as exports slow",
+	"Wipro cuts revenue guidance citing weak discretionary spending",
+	"Sensex, Nifty end flat ahead of the RBI policy decision",
+}
+
+// benchmarkAnalyzer loads the default model from configs/model.yaml, skipping
+// the benchmark when ONNX Runtime or the model file isn't available.
+func benchmarkAnalyzer(b *testing.B) *Analyzer {
+	b.Helper()
+	cfg, err := LoadModelConfig(filepath.Join("..", "..", DefaultModelConfigPath))
+	if err != nil {
+		b.Skip(err)
+	}
+	def, err := cfg.Model("")
+	if err != nil || def.Type != ModelTypeONNX {
+		b.Skip("default model is not an ONNX model")
+	}
+	def.Path = filepath.Join("..", "..", def.Path)
+	def.Vocab = filepath.Join("..", "..", def.Vocab)
+	a, err := NewAnalyzer(def)
+	if err != nil {
+		b.Skip(err)
+	}
+	b.Cleanup(func() { a.Close() })
+	return a
+}
+
+// batchTexts repeats the benchmark texts to n texts.
+func batchTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = benchmarkTexts[i%len(benchmarkTexts)]
+	}
+	return texts
+}
+
+// BenchmarkAnalyze scores texts one model run per call, as the bot did
+// before batching.
+func BenchmarkAnalyze(b *testing.B) {
+	a := benchmarkAnalyzer(b)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := a.Analyze(benchmarkTexts[i%len(benchmarkTexts)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// Concurrent callers share the session pool.
+	b.Run("parallel", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if _, err := a.Analyze(benchmarkTexts[i%len(benchmarkTexts)]); err != nil {
+					b.Error(err)
+					return
+				}
+				i++
+			}
+		})
+	})
+}
+
+// BenchmarkAnalyzeBatch scores the same texts in batched model runs. Compare
+// its ns/text with the ns/op of BenchmarkAnalyze.
+func BenchmarkAnalyzeBatch(b *testing.B) {
+	a := benchmarkAnalyzer(b)
+	for _, n := range []int{1, a.def.BatchSize, 4 * a.def.BatchSize} {
+		texts := batchTexts(n)
+		b.Run(fmt.Sprintf("texts=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, r := range a.AnalyzeBatch(texts) {
+					if r.Err != nil {
+						b.Fatal(r.Err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/text")
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	shadowComparisons = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_shadow_comparisons_total",
+			Help: "Shadow model predictions compared with the primary model, by outcome (agree, disagree, error, dropped)",
+		},
+		[]string{"shadow", "outcome"},
+	)
+	shadowLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_shadow_latency_seconds",
+			Help:    "Per-text latency of the primary and shadow models on the same inputs",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 12), // 1ms to ~2s
+		},
+		[]string{"model", "role"},
+	)
+	shadowScoreDiff = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sentiment_shadow_score_diff",
+			Help:    "Absolute difference between the shadow and primary sentiment scores",
+			Buckets: prometheus.LinearBuckets(0.1, 0.2, 10), // 0.1 to 1.9
+		},
+		[]string{"shadow"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(shadowComparisons, shadowLatency, shadowScoreDiff)
+}
+
+// ShadowConfig lists models that score the same inputs as the primary model
+// without affecting its results, to trial them on live traffic.
+type ShadowConfig struct {
+	Models    []string `yaml:"models"`
+	Log       string   `yaml:"log"`        // JSON lines file for disagreements, none if empty
+	QueueSize int      `yaml:"queue_size"` // texts waiting per shadow before new ones are dropped
+}
+
+// ShadowDisagreement is one line of the shadow log.
+type ShadowDisagreement struct {
+	Time           time.Time `json:"time"`
+	Text           string    `json:"text"`
+	Primary        string    `json:"primary"`
+	PrimaryLabel   string    `json:"primary_label"`
+	PrimaryScore   float32   `json:"primary_score"`
+	PrimaryLatency float64   `json:"primary_latency_seconds"`
+	Shadow         string    `json:"shadow"`
+	ShadowLabel    string    `json:"shadow_label"`
+	ShadowScore    float32   `json:"shadow_score"`
+	ShadowLatency  float64   `json:"shadow_latency_seconds"`
+}
+
+// ShadowAnalyzer returns the primary model's results unchanged and, in the
+// background, scores the same texts with each shadow model to compare them.
+// A shadow never delays the primary: each has its own queue, and texts are
+// dropped when it falls behind.
+type ShadowAnalyzer struct {
+	primary SentimentAnalyzer
+	shadows []*shadowModel
+	labels  *LabelRouter // queues disagreements for labelling, nil when off
+
+	mu     sync.RWMutex // held for reading while queueing shadow work
+	closed bool
+
+	log *jsonLog
+}
+
+type shadowModel struct {
+	name     string
+	analyzer SentimentAnalyzer
+	queue    chan shadowJob
+	done     chan struct{}
+}
+
+type shadowJob struct {
+	texts   []string
+	long    bool
+	primary []BatchResult
+}
+
+// newShadowAnalyzer wraps primary with the configured shadows. A shadow that
+// can't be opened is reported and skipped rather than failing the primary.
+// labels may be nil.
+func newShadowAnalyzer(cfg *ModelConfig, primary SentimentAnalyzer, labels *LabelRouter) (*ShadowAnalyzer, error) {
+	s := &ShadowAnalyzer{primary: primary, labels: labels}
+	if cfg.Shadow.Log != "" {
+		log, err := openJSONLog(cfg.Shadow.Log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open shadow log: %w", err)
+		}
+		s.log = log
+	}
+
+	queueSize := cfg.Shadow.QueueSize
+	if queueSize <= 0 {
+		queueSize = 256
+	}
+	for _, name := range cfg.Shadow.Models {
+		def, err := cfg.Model(name)
+		if err == nil {
+			var analyzer SentimentAnalyzer
+			analyzer, err = cfg.NewAnalyzer(def)
+			if err == nil {
+				sm := &shadowModel{name: name, analyzer: analyzer, queue: make(chan shadowJob, queueSize), done: make(chan struct{})}
+				s.shadows = append(s.shadows, sm)
+				go s.runShadow(sm)
+				continue
+			}
+		}
+		fmt.Printf("Shadow sentiment model %q unavailable, skipping it: %v\n", name, err)
+	}
+	return s, nil
+}
+
+// Analyze returns the primary model's result.
+func (s *ShadowAnalyzer) Analyze(text string) (SentimentResult, error) {
+	res, err := s.primary.Analyze(text)
+	s.enqueue(shadowJob{texts: []string{text}, primary: []BatchResult{{SentimentResult: res, Err: err}}})
+	return res, err
+}
+
+// AnalyzeLong returns the primary model's result.
+func (s *ShadowAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	res, err := s.primary.AnalyzeLong(text)
+	s.enqueue(shadowJob{texts: []string{text}, long: true, primary: []BatchResult{{SentimentResult: res, Err: err}}})
+	return res, err
+}
+
+// AnalyzeBatch returns the primary model's results.
+func (s *ShadowAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	results := s.primary.AnalyzeBatch(texts)
+	s.enqueue(shadowJob{texts: texts, primary: results})
+	return results
+}
+
+// Explain explains the primary model's prediction.
+func (s *ShadowAnalyzer) Explain(text string) (Explanation, error) {
+	return Explain(s.primary, text)
+}
+
+// Close waits for queued shadow work, then closes the shadows and the primary.
+func (s *ShadowAnalyzer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for _, sm := range s.shadows {
+		close(sm.queue)
+		<-sm.done
+		errs = append(errs, sm.analyzer.Close())
+	}
+	if s.log != nil {
+		errs = append(errs, s.log.Close())
+	}
+	errs = append(errs, s.primary.Close())
+	return errors.Join(errs...)
+}
+
+func (s *ShadowAnalyzer) enqueue(job shadowJob) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	for _, sm := range s.shadows {
+		select {
+		case sm.queue <- job:
+		default:
+			shadowComparisons.WithLabelValues(sm.name, "dropped").Add(float64(len(job.texts)))
+		}
+	}
+}
+
+func (s *ShadowAnalyzer) runShadow(sm *shadowModel) {
+	defer close(sm.done)
+	for job := range sm.queue {
+		var results []BatchResult
+		if job.long {
+			res, err := sm.analyzer.AnalyzeLong(job.texts[0])
+			results = []BatchResult{{SentimentResult: res, Err: err}}
+		} else {
+			results = sm.analyzer.AnalyzeBatch(job.texts)
+		}
+		for i := range job.texts {
+			s.compare(sm.name, job.texts[i], job.primary[i], results[i])
+		}
+	}
+}
+
+// compare records one shadow prediction against the primary's. Texts the
+// primary failed on are not compared.
+func (s *ShadowAnalyzer) compare(shadow, text string, primary, res BatchResult) {
+	if primary.Err != nil {
+		return
+	}
+	if res.Err != nil {
+		shadowComparisons.WithLabelValues(shadow, "error").Inc()
+		return
+	}
+
+	shadowLatency.WithLabelValues(primary.Model, "primary").Observe(primary.Latency.Seconds())
+	shadowLatency.WithLabelValues(shadow, "shadow").Observe(res.Latency.Seconds())
+	shadowScoreDiff.WithLabelValues(shadow).Observe(math.Abs(float64(res.Score - primary.Score)))
+	if res.Label == primary.Label {
+		shadowComparisons.WithLabelValues(shadow, "agree").Inc()
+		return
+	}
+	shadowComparisons.WithLabelValues(shadow, "disagree").Inc()
+	if s.labels != nil {
+		s.labels.RouteShadow(text, primary.SentimentResult, res.SentimentResult, shadow)
+	}
+
+	if s.log == nil {
+		return
+	}
+	s.log.Write(ShadowDisagreement{
+		Time:           time.Now(),
+		Text:           text,
+		Primary:        primary.Model,
+		PrimaryLabel:   primary.Label,
+		PrimaryScore:   primary.Score,
+		PrimaryLatency: primary.Latency.Seconds(),
+		Shadow:         shadow,
+		ShadowLabel:    res.Label,
+		ShadowScore:    res.Score,
+		ShadowLatency:  res.Latency.Seconds(),
+	})
+}
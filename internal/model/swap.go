@@ -0,0 +1,203 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	modelSwaps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiment_model_swaps_total",
+			Help: "Hot swaps of the sentiment model by outcome (ok or failed)",
+		},
+		[]string{"outcome"},
+	)
+	modelInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiment_model_info",
+			Help: "The sentiment model version being served (value is always 1)",
+		},
+		[]string{"model", "version"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(modelSwaps, modelInfo)
+}
+
+// swapProbe is scored by a new model before it replaces the current one.
+const swapProbe = "Company reports quarterly results in line with estimates"
+
+// SwappableAnalyzer serves one model and can replace it with another version
+// at runtime. Calls in flight keep using the model they started with; the
+// old model is closed once they have all returned.
+type SwappableAnalyzer struct {
+	cfg  *ModelConfig
+	name string
+
+	swapMu  sync.Mutex   // serializes swaps
+	mu      sync.RWMutex // guards current
+	current *generation
+}
+
+type generation struct {
+	analyzer SentimentAnalyzer
+	def      ModelDefinition
+	inFlight sync.WaitGroup
+}
+
+// NewSwappableAnalyzer opens the named model like OpenSentimentAnalyzer.
+// Later swaps load versions of that model even if it started on the fallback.
+func NewSwappableAnalyzer(cfg *ModelConfig, name string) (*SwappableAnalyzer, error) {
+	want, err := cfg.Model(name)
+	if err != nil {
+		return nil, err
+	}
+	analyzer, def, err := openSentimentAnalyzer(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	modelInfo.WithLabelValues(def.Name, def.Version).Set(1)
+	return &SwappableAnalyzer{cfg: cfg, name: want.Name, current: &generation{analyzer: analyzer, def: def}}, nil
+}
+
+// Current returns the definition of the model being served.
+func (s *SwappableAnalyzer) Current() ModelDefinition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.def
+}
+
+// SwapVersion switches to a registered version of the model, or to the most
+// recently registered one if version is empty.
+func (s *SwappableAnalyzer) SwapVersion(version string) error {
+	registry := s.cfg.Registry()
+	if version == "" {
+		latest, found := registry.Latest(s.name)
+		if !found {
+			return fmt.Errorf("model %q has no registered versions", s.name)
+		}
+		version = latest.Version
+	}
+	def, err := s.cfg.Model(s.name)
+	if err != nil {
+		return err
+	}
+	def, err = registry.Apply(def, version)
+	if err != nil {
+		return err
+	}
+	return s.Swap(def)
+}
+
+// Swap loads def, checks that it scores a probe text and then atomically
+// makes it the served model. On any failure the current model stays.
+// Unlike at startup there is no fallback: a swap either succeeds or changes
+// nothing.
+func (s *SwappableAnalyzer) Swap(def ModelDefinition) error {
+	s.swapMu.Lock()
+	defer s.swapMu.Unlock()
+
+	cfg := *s.cfg
+	cfg.Fallback = ""
+	cfg.Models = make(map[string]ModelDefinition, len(s.cfg.Models))
+	for name, d := range s.cfg.Models {
+		cfg.Models[name] = d
+	}
+	cfg.Models[def.Name] = def
+
+	analyzer, _, err := openSentimentAnalyzer(&cfg, def.Name)
+	if err == nil {
+		// The probe is not a served prediction, so it bypasses monitoring.
+		probe := analyzer
+		if m, ok := analyzer.(*MonitoredAnalyzer); ok {
+			probe = m.inner
+		}
+		if _, err = probe.Analyze(swapProbe); err != nil {
+			analyzer.Close()
+		}
+	}
+	if err != nil {
+		modelSwaps.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to swap to %s %s: %w", def.Name, def.Version, err)
+	}
+
+	s.mu.Lock()
+	old := s.current
+	s.current = &generation{analyzer: analyzer, def: def}
+	s.mu.Unlock()
+
+	modelSwaps.WithLabelValues("ok").Inc()
+	modelInfo.WithLabelValues(old.def.Name, old.def.Version).Set(0)
+	modelInfo.WithLabelValues(def.Name, def.Version).Set(1)
+	fmt.Printf("Sentiment model swapped from %s %s to %s %s\n", old.def.Name, old.def.Version, def.Name, def.Version)
+
+	go func() {
+		old.inFlight.Wait()
+		old.analyzer.Close()
+	}()
+	return nil
+}
+
+// acquire returns the current generation, which stays open until the caller
+// marks its call done with inFlight.Done.
+func (s *SwappableAnalyzer) acquire() *generation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g := s.current
+	g.inFlight.Add(1)
+	return g
+}
+
+// Analyze scores text with the current model.
+func (s *SwappableAnalyzer) Analyze(text string) (SentimentResult, error) {
+	g := s.acquire()
+	defer g.inFlight.Done()
+	return g.analyzer.Analyze(text)
+}
+
+// AnalyzeLong scores a long text with the current model.
+func (s *SwappableAnalyzer) AnalyzeLong(text string) (SentimentResult, error) {
+	g := s.acquire()
+	defer g.inFlight.Done()
+	return g.analyzer.AnalyzeLong(text)
+}
+
+// AnalyzeBatch scores texts with the current model.
+func (s *SwappableAnalyzer) AnalyzeBatch(texts []string) []BatchResult {
+	g := s.acquire()
+	defer g.inFlight.Done()
+	return g.analyzer.AnalyzeBatch(texts)
+}
+
+// Explain explains a prediction of the current model.
+func (s *SwappableAnalyzer) Explain(text string) (Explanation, error) {
+	g := s.acquire()
+	defer g.inFlight.Done()
+	return Explain(g.analyzer, text)
+}
+
+// Drift returns the drift detector of the current model, or nil if drift
+// detection is off.
+func (s *SwappableAnalyzer) Drift() *DriftDetector {
+	g := s.acquire()
+	defer g.inFlight.Done()
+	if m, ok := g.analyzer.(*MonitoredAnalyzer); ok {
+		return m.Drift()
+	}
+	return nil
+}
+
+// Close waits for calls in flight and closes the current model.
+func (s *SwappableAnalyzer) Close() error {
+	s.swapMu.Lock()
+	defer s.swapMu.Unlock()
+	s.mu.RLock()
+	g := s.current
+	s.mu.RUnlock()
+	g.inFlight.Wait()
+	return g.analyzer.Close()
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwappableAnalyzerSwapsCalibration(t *testing.T) {
+	dir := t.TempDir()
+	labels := []string{"negative", "neutral", "positive"}
+	calibrations := map[string]*Calibration{
+		"v1": {Method: CalibrateTemperature, Labels: labels, Temperature: 3},
+		"v2": {Method: CalibratePlatt, Labels: labels, Platt: []PlattParams{{A: 2, B: 0.5}, {A: 1, B: 0}, {A: 0.5, B: -0.5}}},
+	}
+	paths := make(map[string]string)
+	for version, c := range calibrations {
+		paths[version] = filepath.Join(dir, version+".json")
+		if err := c.Save(paths[version]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	def := ModelDefinition{Name: "lexicon", Type: ModelTypeLexicon, Version: "v1", CalibrationPath: paths["v1"]}
+	def.applyDefaults()
+	if err := def.loadCalibration(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ModelConfig{
+		Default: "lexicon",
+		Models:  map[string]ModelDefinition{"lexicon": def},
+		registry: &ModelRegistry{Models: map[string][]ModelVersion{"lexicon": {
+			{Version: "v1", Calibration: paths["v1"]},
+			{Version: "v2", Calibration: paths["v2"]},
+			{Version: "v3"},
+		}}},
+	}
+	s, err := NewSwappableAnalyzer(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	const text = "Infosys profit surged on strong demand"
+	raw, err := NewLexiconAnalyzer(ModelDefinition{}).Analyze(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawProbs := make([]float32, len(labels))
+	for i, label := range labels {
+		rawProbs[i] = raw.Probabilities[label]
+	}
+
+	for _, version := range []string{"v1", "v2", "v3", "v1"} {
+		if version != s.Current().Version {
+			if err := s.SwapVersion(version); err != nil {
+				t.Fatalf("swap to %s: %v", version, err)
+			}
+		}
+		res, err := s.Analyze(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := rawProbs
+		if c := calibrations[version]; c != nil {
+			want = c.Apply(rawProbs)
+		}
+		if res.ModelVersion != version || res.Calibrated != (calibrations[version] != nil) {
+			t.Errorf("%s: result of %s, calibrated %v", version, res.ModelVersion, res.Calibrated)
+		}
+		for i, label := range labels {
+			if math.Abs(float64(res.Probabilities[label]-want[i])) > 1e-6 {
+				t.Errorf("%s: p(%s) = %v, want %v", version, label, res.Probabilities[label], want[i])
+			}
+		}
+	}
+}
+
+func TestSwappableAnalyzerFailedSwapKeepsModel(t *testing.T) {
+	dir := t.TempDir()
+	def := ModelDefinition{Name: "finbert", Type: ModelTypeLexicon, Version: "lexicon-1"}
+	def.applyDefaults()
+	cfg := &ModelConfig{Default: "finbert", Fallback: "finbert", Models: map[string]ModelDefinition{"finbert": def}, registry: &ModelRegistry{}}
+	s, err := NewSwappableAnalyzer(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	broken := ModelDefinition{
+		Name:    "finbert",
+		Version: "2.0",
+		Path:    writeFile(t, dir, "model.onnx", []byte(lfsPointer)),
+		Vocab:   writeFile(t, dir, "vocab.txt", []byte("[PAD]\n[UNK]\n")),
+		Labels:  []string{"negative", "neutral", "positive"},
+	}
+	if err := s.Swap(broken); !errors.Is(err, ErrLFSPointer) {
+		t.Fatalf("swap to an LFS pointer: %v", err)
+	}
+	if err := s.SwapVersion("9.9"); err == nil {
+		t.Fatal("swap to an unregistered version succeeded")
+	}
+
+	if got := s.Current(); got.Version != "lexicon-1" {
+		t.Errorf("serving %s after failed swaps", got.Version)
+	}
+	res, err := s.Analyze("Infosys profit surged")
+	if err != nil || res.ModelVersion != "lexicon-1" || res.Label != "positive" {
+		t.Errorf("Analyze after failed swaps = %+v, %v", res, err)
+	}
+}
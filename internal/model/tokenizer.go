@@ -0,0 +1,291 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	clsToken = "[CLS]"
+	sepToken = "[SEP]"
+	padToken = "[PAD]"
+	unkToken = "[UNK]"
+
+	maxCharsPerWord = 100
+)
+
+// WordPieceTokenizer is a pure-Go port of the HuggingFace BertTokenizer used by
+// FinBERT (uncased): basic tokenization with lowercasing, accent stripping and
+// punctuation splitting, followed by greedy longest-match WordPiece.
+type WordPieceTokenizer struct {
+	vocab     map[string]int64
+	maxLength int
+	lowercase bool
+
+	clsID, sepID, padID, unkID int64
+}
+
+// LoadWordPieceTokenizer reads a BERT vocab.txt (one token per line, id = line number).
+func LoadWordPieceTokenizer(vocabPath string, maxLength int) (*WordPieceTokenizer, error) {
+	f, err := os.Open(vocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocab: %w", err)
+	}
+	defer f.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(f)
+	var id int64
+	for scanner.Scan() {
+		token := strings.TrimRight(scanner.Text(), "\r")
+		if _, found := vocab[token]; !found {
+			vocab[token] = id
+		}
+		id++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocab: %w", err)
+	}
+
+	t := &WordPieceTokenizer{vocab: vocab, maxLength: maxLength, lowercase: true}
+	for token, dst := range map[string]*int64{clsToken: &t.clsID, sepToken: &t.sepID, padToken: &t.padID, unkToken: &t.unkID} {
+		id, found := vocab[token]
+		if !found {
+			return nil, fmt.Errorf("vocab %s is missing special token %s", vocabPath, token)
+		}
+		*dst = id
+	}
+	if maxLength < 2 {
+		return nil, fmt.Errorf("max length %d is too small for [CLS] and [SEP]", maxLength)
+	}
+	return t, nil
+}
+
+// Encode tokenizes text into [CLS] tokens [SEP], truncated and padded to the
+// tokenizer's max length, like the Python tokenizer with
+// padding="max_length", truncation=True.
+func (t *WordPieceTokenizer) Encode(text string) TokenizedOutput {
+	return t.encodePieces(t.Tokenize(text))
+}
+
+// EncodeWindows splits long texts into overlapping windows of at most
+// max length tokens each, consecutive windows sharing overlap tokens. At most
+// maxChunks windows are returned (0 means no limit); short texts yield a
+// single window identical to Encode.
+func (t *WordPieceTokenizer) EncodeWindows(text string, overlap, maxChunks int) []TokenizedOutput {
+	pieces := t.Tokenize(text)
+	size := t.maxLength - 2
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	stride := size - overlap
+
+	var windows []TokenizedOutput
+	for start := 0; ; start += stride {
+		end := start + size
+		if end > len(pieces) {
+			end = len(pieces)
+		}
+		windows = append(windows, t.encodePieces(pieces[start:end]))
+		if end == len(pieces) {
+			break
+		}
+		if maxChunks > 0 && len(windows) == maxChunks {
+			windows[len(windows)-1].Truncated = true
+			break
+		}
+	}
+	return windows
+}
+
+// encodePieces adds [CLS]/[SEP], truncates and pads WordPiece tokens.
+func (t *WordPieceTokenizer) encodePieces(pieces []string) TokenizedOutput {
+	out := TokenizedOutput{
+		InputIDs:      make([]int64, t.maxLength),
+		AttentionMask: make([]int64, t.maxLength),
+	}
+	if len(pieces) > t.maxLength-2 {
+		pieces = pieces[:t.maxLength-2]
+		out.Truncated = true
+	}
+
+	out.InputIDs[0] = t.clsID
+	for i, p := range pieces {
+		out.InputIDs[i+1] = t.tokenID(p)
+	}
+	out.InputIDs[len(pieces)+1] = t.sepID
+	for i := range out.InputIDs {
+		if i < len(pieces)+2 {
+			out.AttentionMask[i] = 1
+		} else {
+			out.InputIDs[i] = t.padID
+		}
+	}
+	return out
+}
+
+// Tokenize splits text into WordPiece tokens without special tokens.
+func (t *WordPieceTokenizer) Tokenize(text string) []string {
+	var pieces []string
+	for _, word := range t.basicTokenize(text) {
+		pieces = append(pieces, t.wordPiece(word)...)
+	}
+	return pieces
+}
+
+func (t *WordPieceTokenizer) tokenID(token string) int64 {
+	if id, found := t.vocab[token]; found {
+		return id
+	}
+	return t.unkID
+}
+
+// basicTokenize mirrors transformers' BasicTokenizer.
+func (t *WordPieceTokenizer) basicTokenize(text string) []string {
+	text = cleanText(text)
+	text = tokenizeCJK(text)
+	text = norm.NFC.String(text)
+
+	var words []string
+	for _, token := range strings.Fields(text) {
+		if t.lowercase {
+			token = stripAccents(strings.ToLower(token))
+		}
+		words = append(words, splitOnPunctuation(token)...)
+	}
+	return words
+}
+
+// wordPiece applies greedy longest-match-first subword tokenization.
+func (t *WordPieceTokenizer) wordPiece(word string) []string {
+	chars := []rune(word)
+	if len(chars) > maxCharsPerWord {
+		return []string{unkToken}
+	}
+
+	var pieces []string
+	for start := 0; start < len(chars); {
+		end := len(chars)
+		cur := ""
+		for start < end {
+			sub := string(chars[start:end])
+			if start > 0 {
+				sub = "##" + sub
+			}
+			if _, found := t.vocab[sub]; found {
+				cur = sub
+				break
+			}
+			end--
+		}
+		if cur == "" {
+			return []string{unkToken}
+		}
+		pieces = append(pieces, cur)
+		start = end
+	}
+	return pieces
+}
+
+// cleanText drops invalid and control characters and maps whitespace to spaces.
+func cleanText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r == 0 || r == 0xFFFD || isControl(r) {
+			continue
+		}
+		if isWhitespace(r) {
+			b.WriteRune(' ')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokenizeCJK surrounds CJK ideographs with spaces so each is its own word.
+func tokenizeCJK(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if isCJK(r) {
+			b.WriteRune(' ')
+			b.WriteRune(r)
+			b.WriteRune(' ')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stripAccents removes combining marks after NFD decomposition.
+func stripAccents(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// splitOnPunctuation makes every punctuation character a separate word.
+func splitOnPunctuation(word string) []string {
+	var out []string
+	var cur []rune
+	for _, r := range word {
+		if isPunctuation(r) {
+			if len(cur) > 0 {
+				out = append(out, string(cur))
+				cur = cur[:0]
+			}
+			out = append(out, string(r))
+			continue
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		out = append(out, string(cur))
+	}
+	return out
+}
+
+func isWhitespace(r rune) bool {
+	if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+		return true
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+func isControl(r rune) bool {
+	if r == '\t' || r == '\n' || r == '\r' {
+		return false
+	}
+	return unicode.In(r, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs)
+}
+
+// isPunctuation treats all non-alphanumeric ASCII as punctuation, as BERT
+// does for characters like "$" and "^" that Unicode doesn't class as P*.
+func isPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+func isCJK(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) ||
+		(r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) ||
+		(r >= 0x2B740 && r <= 0x2B81F) ||
+		(r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0x2F800 && r <= 0x2FA1F)
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The small vocab in testdata exercises every step of BertTokenizer; the
+// expected pieces follow transformers' BasicTokenizer and WordpieceTokenizer.
+func loadTestTokenizer(t *testing.T, maxLength int) *WordPieceTokenizer {
+	t.Helper()
+	tok, err := LoadWordPieceTokenizer(filepath.Join("testdata", "tokenizer", "vocab.txt"), maxLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestTokenize(t *testing.T) {
+	tok := loadTestTokenizer(t, 128)
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"lowercase and punctuation", "Infosys shares ROSE 5%.", "infosys shares rose 5 % ."},
+		{"accents", "Café Résumé naïve", "cafe resume naive"},
+		{"CJK", "中国banking", "中 国 bank ##ing"},
+		{"subwords", "unbelievable banks", "un ##believ ##able bank ##s"},
+		{"symbols are punctuation", "$50 (naive)", "$ 5 ##0 ( naive )"},
+		{"unknown words", "Q2: TCS's", "q ##2 [UNK] [UNK] ' s"},
+		{"unknown suffix makes the word unknown", "sharex", "[UNK]"},
+		{"whitespace and control characters", "the shares\x00\u200b\trose\r\n", "the shares rose"},
+		{"words over 100 characters", strings.Repeat("s", 101) + " the", "[UNK] the"},
+		{"empty", " \t ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tok.Tokenize(tt.text), " "); got != tt.want {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePadsAndTruncates(t *testing.T) {
+	tok := loadTestTokenizer(t, 8)
+	got := tok.Encode("the shares")
+	if want := []int64{2, 4, 6, 3, 0, 0, 0, 0}; !slices.Equal(got.InputIDs, want) {
+		t.Errorf("input ids = %v, want %v", got.InputIDs, want)
+	}
+	if want := []int64{1, 1, 1, 1, 0, 0, 0, 0}; !slices.Equal(got.AttentionMask, want) || got.Truncated {
+		t.Errorf("attention mask = %v truncated %v, want %v", got.AttentionMask, got.Truncated, want)
+	}
+
+	// Exactly max_length-2 pieces fit without truncation.
+	tok = loadTestTokenizer(t, 6)
+	if got := tok.Encode("infosys shares rose 5"); got.Truncated || got.InputIDs[5] != 3 {
+		t.Errorf("4 pieces in max length 6: %v truncated %v", got.InputIDs, got.Truncated)
+	}
+	got = tok.Encode("Infosys shares rose 5%.")
+	if want := []int64{2, 5, 6, 7, 16, 3}; !slices.Equal(got.InputIDs, want) || !got.Truncated {
+		t.Errorf("input ids = %v truncated %v, want %v truncated", got.InputIDs, got.Truncated, want)
+	}
+}
+
+// goldenEncoding is one case written by scripts/tokenizer_golden.py.
+type goldenEncoding struct {
+	Text          string   `json:"text"`
+	MaxLength     int      `json:"max_length"`
+	Tokens        []string `json:"tokens"`
+	InputIDs      []int64  `json:"input_ids"`
+	AttentionMask []int64  `json:"attention_mask"`
+}
+
+// TestFinBERTParity checks the tokenizer token for token against the
+// HuggingFace BertTokenizer. The golden encodings use golden_vocab.txt, a
+// small vocab in finbert's format that covers every case, so the check runs
+// without models/vocab.txt.
+func TestFinBERTParity(t *testing.T) {
+	dir := filepath.Join("testdata", "tokenizer")
+	raw, err := os.ReadFile(filepath.Join(dir, "finbert_golden.json"))
+	if err != nil {
+		t.Fatalf("%v; generate the golden encodings with scripts/tokenizer_golden.py", err)
+	}
+	var golden []goldenEncoding
+	if err := json.Unmarshal(raw, &golden); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range golden {
+		tok, err := LoadWordPieceTokenizer(filepath.Join(dir, "golden_vocab.txt"), g.MaxLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tok.Tokenize(g.Text); !slices.Equal(got, g.Tokens) {
+			t.Errorf("Tokenize(%q)\n got  %q\n want %q", g.Text, got, g.Tokens)
+			continue
+		}
+		got := tok.Encode(g.Text)
+		if !slices.Equal(got.InputIDs, g.InputIDs) || !slices.Equal(got.AttentionMask, g.AttentionMask) {
+			t.Errorf("Encode(%q)\n got  %v %v\n want %v %v", g.Text, got.InputIDs, got.AttentionMask, g.InputIDs, g.AttentionMask)
+		}
+	}
+}
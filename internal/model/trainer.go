@@ -1,3 +1,601 @@
 package model
 
-// Add sentiment analysis code that loads the ONNX model here.
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// istZone is Indian Standard Time, fixed so that no tzdata is needed.
+var istZone = time.FixedZone("IST", 5*60*60+30*60)
+
+// WeakLabelConfig sets how price reactions become labels, in the
+// weak_labeling section of configs/model.yaml.
+type WeakLabelConfig struct {
+	Benchmark     string          `yaml:"benchmark"`      // price series the excess return is measured against
+	Horizons      []time.Duration `yaml:"horizons"`       // returns are computed at each
+	LabelHorizon  time.Duration   `yaml:"label_horizon"`  // the horizon that decides the label, the first if 0
+	Positive      float64         `yaml:"positive"`       // excess return at or above which the label is positive
+	Negative      float64         `yaml:"negative"`       // at or below which it is negative (a negative number)
+	NeutralBand   float64         `yaml:"neutral_band"`   // absolute excess return at or below which it is neutral
+	MaxEntryGap   time.Duration   `yaml:"max_entry_gap"`  // oldest usable price before the article
+	MaxExitGap    time.Duration   `yaml:"max_exit_gap"`   // latest usable price after a horizon ends
+	DailyCloseAt  string          `yaml:"daily_close_at"` // IST time of the close for date-only price rows
+	MinTextLength int             `yaml:"min_text_length"`
+}
+
+func (c *WeakLabelConfig) applyDefaults() {
+	if c.Benchmark == "" {
+		c.Benchmark = "NIFTY50"
+	}
+	if len(c.Horizons) == 0 {
+		c.Horizons = []time.Duration{24 * time.Hour, 72 * time.Hour}
+	}
+	if c.LabelHorizon == 0 {
+		c.LabelHorizon = c.Horizons[0]
+	}
+	if c.Positive == 0 {
+		c.Positive = 0.02
+	}
+	if c.Negative == 0 {
+		c.Negative = -0.02
+	}
+	if c.NeutralBand == 0 {
+		c.NeutralBand = 0.005
+	}
+	if c.MaxEntryGap <= 0 {
+		c.MaxEntryGap = 96 * time.Hour // covers a weekend and a holiday
+	}
+	if c.MaxExitGap <= 0 {
+		c.MaxExitGap = 96 * time.Hour
+	}
+	if c.DailyCloseAt == "" {
+		c.DailyCloseAt = "15:30"
+	}
+	if c.MinTextLength <= 0 {
+		c.MinTextLength = 20
+	}
+}
+
+// Validate checks that the thresholds leave room for every label and that
+// the label horizon is one of the horizons.
+func (c WeakLabelConfig) Validate() error {
+	if c.Negative >= 0 || c.Positive <= 0 {
+		return fmt.Errorf("weak labelling needs negative < 0 < positive, got %v and %v", c.Negative, c.Positive)
+	}
+	if c.NeutralBand < 0 || c.NeutralBand > c.Positive || c.NeutralBand > -c.Negative {
+		return fmt.Errorf("neutral band %v must be within the positive and negative thresholds", c.NeutralBand)
+	}
+	found := false
+	for _, h := range c.Horizons {
+		if h < 0 {
+			return fmt.Errorf("horizon %v is negative", h)
+		}
+		found = found || h == c.LabelHorizon
+	}
+	if !found {
+		return fmt.Errorf("label horizon %v is not one of the horizons %v", c.LabelHorizon, c.Horizons)
+	}
+	if _, err := time.Parse("15:04", c.DailyCloseAt); err != nil {
+		return fmt.Errorf("daily_close_at %q is not HH:MM", c.DailyCloseAt)
+	}
+	return nil
+}
+
+// NewsRecord is a stored news article tagged with the tickers it is about.
+// Its JSON matches the article archive cmd/main.go writes with -archive.
+type NewsRecord struct {
+	Ticker      string    `json:"ticker"`
+	Tickers     []string  `json:"tickers,omitempty"` // when an article is about several
+	Source      string    `json:"source"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Text is the title and description the model is trained on.
+func (r NewsRecord) Text() string {
+	title := strings.TrimSpace(r.Title)
+	desc := strings.TrimSpace(r.Description)
+	switch {
+	case desc == "" || strings.HasPrefix(desc, title):
+		if desc != "" {
+			return desc
+		}
+		return title
+	case title == "":
+		return desc
+	}
+	if !strings.ContainsAny(title[len(title)-1:], ".!?") {
+		title += "."
+	}
+	return title + " " + desc
+}
+
+// LoadNewsRecords reads a JSON lines article archive.
+func LoadNewsRecords(path string) ([]NewsRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open news archive: %w", err)
+	}
+	defer f.Close()
+
+	var records []NewsRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var r NewsRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("news archive %s line %d: %w", path, line, err)
+		}
+		records = append(records, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read news archive %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// PriceBar is the closing price of one bar.
+type PriceBar struct {
+	Time  time.Time // when the bar closed
+	Close float64
+}
+
+// PriceSeries is one instrument's bars sorted by time.
+type PriceSeries struct {
+	Symbol string
+	Bars   []PriceBar
+}
+
+// LoadPriceSeries reads a CSV file with a date, datetime or timestamp column
+// (or separate date and time columns) and a close column, as exported by
+// Zerodha Kite and most data vendors. Rows with only a date are taken to close at closeAt (HH:MM IST);
+// times without a zone are IST.
+func LoadPriceSeries(path, symbol, closeAt string) (*PriceSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prices: %w", err)
+	}
+	defer f.Close()
+
+	closeTime, err := time.Parse("15:04", closeAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid close time %q: %w", closeAt, err)
+	}
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prices %s: %w", path, err)
+	}
+	timeCol, clockCol, closeCol := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "date", "datetime", "timestamp":
+			timeCol = i
+		case "time":
+			clockCol = i
+		case "close", "adj close", "adj_close":
+			if closeCol < 0 || strings.HasPrefix(strings.ToLower(name), "adj") {
+				closeCol = i
+			}
+		}
+	}
+	if timeCol < 0 {
+		timeCol, clockCol = clockCol, -1
+	}
+	if timeCol < 0 || closeCol < 0 {
+		return nil, fmt.Errorf("prices %s need date and close columns, got %v", path, header)
+	}
+
+	s := &PriceSeries{Symbol: symbol}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prices %s: %w", path, err)
+		}
+		if timeCol >= len(rec) || closeCol >= len(rec) || clockCol >= len(rec) {
+			continue
+		}
+		stamp := strings.TrimSpace(rec[timeCol])
+		if clockCol >= 0 {
+			stamp += " " + strings.TrimSpace(rec[clockCol])
+		}
+		t, err := parseBarTime(stamp, closeTime)
+		if err != nil {
+			line, _ := cr.FieldPos(timeCol)
+			return nil, fmt.Errorf("prices %s line %d: %w", path, line, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(rec[closeCol]), 64)
+		if err != nil || price <= 0 {
+			continue // missing or bad close, as in vendor files with "null" rows
+		}
+		s.Bars = append(s.Bars, PriceBar{Time: t, Close: price})
+	}
+	if len(s.Bars) == 0 {
+		return nil, fmt.Errorf("prices %s have no bars", path)
+	}
+	sort.Slice(s.Bars, func(i, j int) bool { return s.Bars[i].Time.Before(s.Bars[j].Time) })
+	return s, nil
+}
+
+var barTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700", // Kite historical data
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+}
+
+func parseBarTime(s string, closeAt time.Time) (time.Time, error) {
+	for _, layout := range barTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, istZone); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02", "02-01-2006", "02-Jan-2006", "2006/01/02"} {
+		if d, err := time.ParseInLocation(layout, s, istZone); err == nil {
+			return time.Date(d.Year(), d.Month(), d.Day(), closeAt.Hour(), closeAt.Minute(), 0, 0, istZone), nil
+		}
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// LoadPriceDir reads every <SYMBOL>.csv in dir.
+func LoadPriceDir(dir, closeAt string) (map[string]*PriceSeries, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no price files in %s", dir)
+	}
+	series := make(map[string]*PriceSeries, len(paths))
+	for _, path := range paths {
+		symbol := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		s, err := LoadPriceSeries(path, symbol, closeAt)
+		if err != nil {
+			return nil, err
+		}
+		series[symbol] = s
+	}
+	return series, nil
+}
+
+// before returns the last bar strictly before t.
+func (s *PriceSeries) before(t time.Time) (PriceBar, bool) {
+	i := sort.Search(len(s.Bars), func(i int) bool { return !s.Bars[i].Time.Before(t) })
+	if i == 0 {
+		return PriceBar{}, false
+	}
+	return s.Bars[i-1], true
+}
+
+// atOrAfter returns the first bar at or after t.
+func (s *PriceSeries) atOrAfter(t time.Time) (PriceBar, bool) {
+	i := sort.Search(len(s.Bars), func(i int) bool { return !s.Bars[i].Time.Before(t) })
+	if i == len(s.Bars) {
+		return PriceBar{}, false
+	}
+	return s.Bars[i], true
+}
+
+// HorizonReturn is the move over one horizon after an article.
+type HorizonReturn struct {
+	Horizon   string  `json:"horizon"`
+	Stock     float64 `json:"stock"`
+	Benchmark float64 `json:"benchmark"`
+	Excess    float64 `json:"excess"` // Stock - Benchmark
+}
+
+// WeakExample is an article labelled by the price reaction that followed it.
+type WeakExample struct {
+	ID          string          `json:"id"`
+	Text        string          `json:"text"`
+	Label       string          `json:"label"`
+	Ticker      string          `json:"ticker"`
+	Source      string          `json:"source,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	PublishedAt time.Time       `json:"published_at"`
+	EntryAt     time.Time       `json:"entry_at"` // the close the returns are measured from
+	Returns     []HorizonReturn `json:"returns"`
+}
+
+// WeakLabelReport counts what happened to each article and ticker pair.
+type WeakLabelReport struct {
+	Articles   int            `json:"articles"`
+	Pairs      int            `json:"pairs"` // article and ticker combinations
+	Labeled    int            `json:"labeled"`
+	ByLabel    map[string]int `json:"by_label"`
+	NoTicker   int            `json:"no_ticker"`
+	NoPrices   int            `json:"no_prices"`  // ticker without a price series
+	NoEntry    int            `json:"no_entry"`   // no close within MaxEntryGap before the article
+	NoExit     int            `json:"no_exit"`    // a horizon not yet reached or past the data
+	Ambiguous  int            `json:"ambiguous"`  // excess return between the neutral band and a threshold
+	Duplicates int            `json:"duplicates"` // same text and ticker as an earlier article
+	TooShort   int            `json:"too_short"`
+}
+
+// WeakDataset is the result of BuildWeakDataset.
+type WeakDataset struct {
+	Config   WeakLabelConfig `json:"config"`
+	Examples []WeakExample   `json:"-"`
+	Report   WeakLabelReport `json:"report"`
+}
+
+// BuildWeakDataset labels each article by the excess return of its ticker
+// over the benchmark after it was published. Returns are measured from the
+// last close before the article, so the whole reaction is included, to the
+// first close at least each horizon later. An excess return at the label
+// horizon at or above Positive is positive, at or below Negative negative,
+// within NeutralBand neutral; anything else is too ambiguous to use.
+// Articles tagged with several tickers give one example per ticker.
+func BuildWeakDataset(records []NewsRecord, prices map[string]*PriceSeries, cfg WeakLabelConfig) (*WeakDataset, error) {
+	cfg.applyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	bench, found := prices[strings.ToUpper(cfg.Benchmark)]
+	if !found {
+		return nil, fmt.Errorf("no prices for benchmark %s", cfg.Benchmark)
+	}
+
+	d := &WeakDataset{Config: cfg, Report: WeakLabelReport{Articles: len(records), ByLabel: make(map[string]int)}}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		tickers := r.Tickers
+		if r.Ticker != "" && !containsLabel(tickers, r.Ticker) {
+			tickers = append([]string{r.Ticker}, tickers...)
+		}
+		if len(tickers) == 0 {
+			d.Report.NoTicker++
+			continue
+		}
+		text := r.Text()
+		for _, ticker := range tickers {
+			ticker = strings.ToUpper(strings.TrimSpace(ticker))
+			d.Report.Pairs++
+			if len(text) < cfg.MinTextLength {
+				d.Report.TooShort++
+				continue
+			}
+			id := labelItemID(ticker + "\x00" + text)
+			if seen[id] {
+				d.Report.Duplicates++
+				continue
+			}
+			seen[id] = true
+
+			stock, found := prices[ticker]
+			if !found {
+				d.Report.NoPrices++
+				continue
+			}
+			ex, reason := weakExample(r, text, ticker, stock, bench, cfg)
+			switch reason {
+			case "":
+				ex.ID = id
+				d.Examples = append(d.Examples, ex)
+				d.Report.Labeled++
+				d.Report.ByLabel[ex.Label]++
+			case "entry":
+				d.Report.NoEntry++
+			case "exit":
+				d.Report.NoExit++
+			case "ambiguous":
+				d.Report.Ambiguous++
+			}
+		}
+	}
+	sort.SliceStable(d.Examples, func(i, j int) bool { return d.Examples[i].PublishedAt.Before(d.Examples[j].PublishedAt) })
+	return d, nil
+}
+
+// weakExample computes the returns of one article and ticker, or the reason
+// it can't be labelled.
+func weakExample(r NewsRecord, text, ticker string, stock, bench *PriceSeries, cfg WeakLabelConfig) (WeakExample, string) {
+	entry, ok := stock.before(r.PublishedAt)
+	if !ok || r.PublishedAt.Sub(entry.Time) > cfg.MaxEntryGap {
+		return WeakExample{}, "entry"
+	}
+	benchEntry, ok := bench.before(r.PublishedAt)
+	if !ok || r.PublishedAt.Sub(benchEntry.Time) > cfg.MaxEntryGap {
+		return WeakExample{}, "entry"
+	}
+
+	ex := WeakExample{
+		Text: text, Ticker: ticker, Source: r.Source, URL: r.URL,
+		PublishedAt: r.PublishedAt, EntryAt: entry.Time,
+	}
+	var labelExcess float64
+	for _, h := range cfg.Horizons {
+		target := r.PublishedAt.Add(h)
+		exit, ok := stock.atOrAfter(target)
+		if !ok || exit.Time.Sub(target) > cfg.MaxExitGap {
+			return WeakExample{}, "exit"
+		}
+		benchExit, ok := bench.atOrAfter(target)
+		if !ok || benchExit.Time.Sub(target) > cfg.MaxExitGap {
+			return WeakExample{}, "exit"
+		}
+		hr := HorizonReturn{
+			Horizon:   formatHorizon(h),
+			Stock:     exit.Close/entry.Close - 1,
+			Benchmark: benchExit.Close/benchEntry.Close - 1,
+		}
+		hr.Excess = hr.Stock - hr.Benchmark
+		ex.Returns = append(ex.Returns, hr)
+		if h == cfg.LabelHorizon {
+			labelExcess = hr.Excess
+		}
+	}
+
+	switch {
+	case labelExcess >= cfg.Positive:
+		ex.Label = "positive"
+	case labelExcess <= cfg.Negative:
+		ex.Label = "negative"
+	case math.Abs(labelExcess) <= cfg.NeutralBand:
+		ex.Label = "neutral"
+	default:
+		return WeakExample{}, "ambiguous"
+	}
+	return ex, ""
+}
+
+// formatHorizon writes whole days as "1d" and other durations as Go does.
+func formatHorizon(h time.Duration) string {
+	if h > 0 && h%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(h/(24*time.Hour))) + "d"
+	}
+	return h.String()
+}
+
+// ParseHorizons parses a comma-separated list of durations, accepting a
+// "d" suffix for days: "1d,3d,4h".
+func ParseHorizons(s string) ([]time.Duration, error) {
+	var horizons []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if days, found := strings.CutSuffix(part, "d"); found {
+			n, err := strconv.ParseFloat(days, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid horizon %q", part)
+			}
+			horizons = append(horizons, time.Duration(n*float64(24*time.Hour)))
+			continue
+		}
+		h, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid horizon %q", part)
+		}
+		horizons = append(horizons, h)
+	}
+	if len(horizons) == 0 {
+		return nil, errors.New("no horizons given")
+	}
+	return horizons, nil
+}
+
+// WeakDatasetExport lists the files Export wrote.
+type WeakDatasetExport struct {
+	Train           string `json:"train"`
+	Validation      string `json:"validation"`
+	Examples        string `json:"examples"`
+	TrainCount      int    `json:"train_count"`
+	ValidationCount int    `json:"validation_count"`
+}
+
+// Export writes the dataset to dir:
+//
+//   - train.csv and validation.csv with text and label columns, the same
+//     format as the labelling queue export, for fine-tuning the sentiment
+//     model with scripts/retrain_sentiment.py;
+//   - examples.jsonl with every example's returns at every horizon, for
+//     training a news-impact model on the returns themselves.
+//
+// The validation split is by time: the latest validationFraction of the
+// examples, so that validation measures how labels learnt from the past
+// carry to later news rather than leaking one story across both sets.
+func (d *WeakDataset) Export(dir string, validationFraction float64) (WeakDatasetExport, error) {
+	if validationFraction < 0 || validationFraction >= 1 {
+		return WeakDatasetExport{}, fmt.Errorf("validation fraction must be in [0, 1), got %v", validationFraction)
+	}
+	if len(d.Examples) == 0 {
+		return WeakDatasetExport{}, errors.New("no labelled examples to export")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return WeakDatasetExport{}, fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+
+	exp := WeakDatasetExport{
+		Train:      filepath.Join(dir, "train.csv"),
+		Validation: filepath.Join(dir, "validation.csv"),
+		Examples:   filepath.Join(dir, "examples.jsonl"),
+	}
+	split := len(d.Examples) - int(math.Round(float64(len(d.Examples))*validationFraction))
+	var train, validation [][]string
+	for i, ex := range d.Examples {
+		row := []string{ex.Text, ex.Label}
+		if i < split {
+			train = append(train, row)
+		} else {
+			validation = append(validation, row)
+		}
+	}
+	exp.TrainCount, exp.ValidationCount = len(train), len(validation)
+	if err := writeDatasetCSV(exp.Train, train); err != nil {
+		return exp, err
+	}
+	if err := writeDatasetCSV(exp.Validation, validation); err != nil {
+		return exp, err
+	}
+
+	f, err := os.Create(exp.Examples)
+	if err != nil {
+		return exp, fmt.Errorf("failed to write dataset: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, ex := range d.Examples {
+		if err = enc.Encode(ex); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return exp, fmt.Errorf("failed to write dataset %s: %w", exp.Examples, err)
+	}
+	return exp, nil
+}
+
+// WriteText prints the report as a table.
+func (r WeakLabelReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Articles\t%d\n", r.Articles)
+	fmt.Fprintf(tw, "Article/ticker pairs\t%d\n", r.Pairs)
+	fmt.Fprintf(tw, "Labelled\t%d\n", r.Labeled)
+	for _, label := range []string{"negative", "neutral", "positive"} {
+		fmt.Fprintf(tw, "  %s\t%d\n", label, r.ByLabel[label])
+	}
+	fmt.Fprintf(tw, "Ambiguous\t%d\n", r.Ambiguous)
+	fmt.Fprintf(tw, "No ticker\t%d\n", r.NoTicker)
+	fmt.Fprintf(tw, "No prices for ticker\t%d\n", r.NoPrices)
+	fmt.Fprintf(tw, "No close before article\t%d\n", r.NoEntry)
+	fmt.Fprintf(tw, "Horizon not covered\t%d\n", r.NoExit)
+	fmt.Fprintf(tw, "Duplicates\t%d\n", r.Duplicates)
+	fmt.Fprintf(tw, "Too short\t%d\n", r.TooShort)
+	return tw.Flush()
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dailySeries has one close at 15:30 IST on each day from Fri 9 Oct 2026.
+func dailySeries(symbol string, closes map[int]float64) *PriceSeries {
+	s := &PriceSeries{Symbol: symbol}
+	for day := 1; day <= 31; day++ {
+		if c, found := closes[day]; found {
+			s.Bars = append(s.Bars, PriceBar{Time: time.Date(2026, 10, day, 15, 30, 0, 0, istZone), Close: c})
+		}
+	}
+	return s
+}
+
+func TestWeakExample(t *testing.T) {
+	// Published Monday 12 Oct before the close: returns run from Friday's
+	// close to the first close a horizon later.
+	published := time.Date(2026, 10, 12, 11, 0, 0, 0, istZone)
+	flat := map[int]float64{9: 100, 12: 100, 13: 100, 14: 100}
+
+	tests := []struct {
+		name     string
+		stock    map[int]float64
+		bench    map[int]float64
+		horizons []time.Duration // 1d if nil
+		label    time.Duration   // label horizon, the first if 0
+		want     string          // label, or the reason there is none
+		excess   float64         // at the label horizon
+	}{
+		{"positive", map[int]float64{9: 100, 13: 103}, map[int]float64{9: 100, 13: 100.5}, nil, 0, "positive", 0.025},
+		{"positive at threshold", map[int]float64{9: 100, 13: 102}, flat, nil, 0, "positive", 0.02},
+		{"negative", map[int]float64{9: 100, 13: 97}, flat, nil, 0, "negative", -0.03},
+		{"negative at threshold", map[int]float64{9: 100, 13: 98}, flat, nil, 0, "negative", -0.02},
+		{"neutral", map[int]float64{9: 100, 13: 100.3}, flat, nil, 0, "neutral", 0.003},
+		{"moved with the market", map[int]float64{9: 100, 13: 103}, map[int]float64{9: 100, 13: 103}, nil, 0, "neutral", 0},
+		{"ambiguous", map[int]float64{9: 100, 13: 101}, flat, nil, 0, "ambiguous", 0},
+		{"later label horizon", map[int]float64{9: 100, 13: 103, 14: 100.2}, flat, []time.Duration{24 * time.Hour, 48 * time.Hour}, 48 * time.Hour, "neutral", 0.002},
+		{"no stock close before", map[int]float64{13: 103}, flat, nil, 0, "entry", 0},
+		{"no benchmark close before", map[int]float64{9: 100, 13: 103}, map[int]float64{12: 100, 13: 100}, nil, 0, "entry", 0},
+		{"entry too old", map[int]float64{5: 100, 13: 103}, flat, nil, 0, "entry", 0},
+		{"horizon past the data", map[int]float64{9: 100, 13: 103}, flat, []time.Duration{24 * time.Hour, 30 * 24 * time.Hour}, 24 * time.Hour, "exit", 0},
+		{"exit too late", map[int]float64{9: 100, 20: 103}, flat, nil, 0, "exit", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			horizons := tt.horizons
+			if horizons == nil {
+				horizons = []time.Duration{24 * time.Hour}
+			}
+			cfg := WeakLabelConfig{Horizons: horizons, LabelHorizon: tt.label, MaxExitGap: 48 * time.Hour}
+			cfg.applyDefaults()
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			r := NewsRecord{Source: "Reuters", URL: "https://example.com/a", PublishedAt: published}
+			ex, reason := weakExample(r, "Infosys wins a large deal", "INFY", dailySeries("INFY", tt.stock), dailySeries("NIFTY50", tt.bench), cfg)
+
+			got := ex.Label
+			if reason != "" {
+				got = reason
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q (returns %+v)", got, tt.want, ex.Returns)
+			}
+			if reason != "" {
+				return
+			}
+			if !ex.EntryAt.Equal(time.Date(2026, 10, 9, 15, 30, 0, 0, istZone)) {
+				t.Errorf("entry at %s, want Friday's close", ex.EntryAt)
+			}
+			if len(ex.Returns) != len(cfg.Horizons) {
+				t.Fatalf("%d returns for %d horizons", len(ex.Returns), len(cfg.Horizons))
+			}
+			for i, h := range cfg.Horizons {
+				hr := ex.Returns[i]
+				if hr.Horizon != formatHorizon(h) || math.Abs(hr.Excess-(hr.Stock-hr.Benchmark)) > 1e-12 {
+					t.Errorf("return %+v for horizon %s", hr, h)
+				}
+				if h == cfg.LabelHorizon && math.Abs(hr.Excess-tt.excess) > 1e-9 {
+					t.Errorf("excess at %s = %v, want %v", hr.Horizon, hr.Excess, tt.excess)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHorizons(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{in: "1d", want: []time.Duration{24 * time.Hour}},
+		{in: "1d,3d,4h", want: []time.Duration{24 * time.Hour, 72 * time.Hour, 4 * time.Hour}},
+		{in: " 1.5d , 90m ,", want: []time.Duration{36 * time.Hour, 90 * time.Minute}},
+		{in: "2h30m", want: []time.Duration{150 * time.Minute}},
+		{in: "", wantErr: true},
+		{in: " , ", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "1w", wantErr: true},
+		{in: "1d,soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHorizons(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHorizons(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseHorizons(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatHorizon(t *testing.T) {
+	for h, want := range map[time.Duration]string{
+		24 * time.Hour:   "1d",
+		72 * time.Hour:   "3d",
+		36 * time.Hour:   "36h0m0s",
+		4 * time.Hour:    "4h0m0s",
+		90 * time.Minute: "1h30m0s",
+	} {
+		if got := formatHorizon(h); got != want {
+			t.Errorf("formatHorizon(%s) = %q, want %q", h, got, want)
+		}
+	}
+}
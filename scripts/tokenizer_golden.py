@@ -0,0 +1,62 @@
+# scripts/tokenizer_golden.py
+#
+# Writes the golden encodings that internal/model/tokenizer_test.go checks the
+# Go WordPiece tokenizer against, using the HuggingFace BertTokenizer with
+# internal/model/testdata/tokenizer/golden_vocab.txt, a small vocab in
+# finbert's format that covers every case, so the test runs without
+# models/vocab.txt:
+#
+#   pip install transformers
+#   python scripts/tokenizer_golden.py
+#
+# Re-run it whenever a case or the vocab changes; add any pieces a new case
+# needs to golden_vocab.txt first.
+
+import json
+import sys
+
+from transformers import BertTokenizer
+
+VOCAB = "internal/model/testdata/tokenizer/golden_vocab.txt"
+OUT = "internal/model/testdata/tokenizer/finbert_golden.json"
+
+CASES = [
+    # (text, max_length)
+    ("Infosys shares rose 5% after Q2 results beat estimates.", 128),
+    ("HDFC Bank's net profit jumps 18.5% YoY to Rs 16,512 crore", 128),
+    ("Café Coffee Day résumé: naïve investors, Zürich & São Paulo", 128),
+    ("ÉLAN Ångström façade — déjà vu", 128),
+    ("中国 stimulus lifts metal stocks; 日本 yen weakens", 128),
+    ("Tata Motors (TTM) -3.2% @ ₹612.40 #EV $TSLA ^NSEI", 128),
+    ("\"Quote\" 'single' [brackets] {braces} <angle> ... !?", 128),
+    ("Reliance Jio\u200b5G\tlaunch\r\nnext\x00week", 128),
+    ("xyzzyplughqwertyasdf unbelievably supercalifragilisticexpialidocious", 128),
+    ("a" * 101 + " long word", 128),
+    ("emoji 🚀📉 and symbols ™ © §", 128),
+    ("Adani Ports Q3 FY26 earnings call transcript: management guides for "
+     "12-14% volume growth, capex of Rs 12,000 crore and net debt to EBITDA "
+     "below 2.5x by FY27", 16),
+    ("Sensex, Nifty end flat ahead of RBI policy", 8),
+]
+
+
+def main():
+    tokenizer = BertTokenizer(VOCAB, do_lower_case=True)
+    golden = []
+    for text, max_length in CASES:
+        enc = tokenizer(text, padding="max_length", max_length=max_length, truncation=True)
+        golden.append({
+            "text": text,
+            "max_length": max_length,
+            "tokens": tokenizer.tokenize(text),
+            "input_ids": enc["input_ids"],
+            "attention_mask": enc["attention_mask"],
+        })
+    with open(OUT, "w", encoding="utf-8") as f:
+        json.dump(golden, f, ensure_ascii=False, indent=1)
+        f.write("\n")
+    print(f"Wrote {len(golden)} cases to {OUT}", file=sys.stderr)
+
+
+if __name__ == "__main__":
+    main()